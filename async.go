@@ -0,0 +1,245 @@
+package rpgoclient
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"go.uber.org/multierr"
+)
+
+// asyncEvent is a reporting request waiting in async pipeline,
+// it is sent only after all events it depends on are delivered
+type asyncEvent struct {
+	req  *http.Request
+	id   string
+	deps []*asyncEvent
+	done chan struct{}
+	err  error
+	// ctx of the request is derived from reporter context, cancel aborts delivery of the event
+	ctx    context.Context
+	cancel context.CancelFunc
+	// aborted is set when delivery is cancelled by caller, which got the error already
+	aborted bool
+}
+
+// asyncReporter delivers reporting requests in background workers preserving causal order:
+// item start waits for its parent start, logs wait for item start,
+// item finish waits for its start and everything reported inside the item, launch finish waits for everything
+type asyncReporter struct {
+	c     *Client
+	queue chan *asyncEvent
+	// ctx is detached from callers contexts, it is cancelled when Close gives up waiting for delivery
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// mu serializes enqueueing so the queue order always respects dependencies
+	mu       sync.Mutex
+	closed   bool
+	starts   map[string]*asyncEvent
+	parents  map[string]string
+	children map[string][]*asyncEvent
+	workers  sync.WaitGroup
+
+	// resMu guards state shared with workers, workers never take mu, so a full queue cannot deadlock them
+	resMu    sync.Mutex
+	inflight map[*asyncEvent]struct{}
+	errs     error
+}
+
+func newAsyncReporter(c *Client, workers int) *asyncReporter {
+	ctx, cancel := context.WithCancel(context.Background())
+	a := &asyncReporter{
+		c:        c,
+		ctx:      ctx,
+		cancel:   cancel,
+		queue:    make(chan *asyncEvent, 1024),
+		inflight: make(map[*asyncEvent]struct{}),
+		starts:   make(map[string]*asyncEvent),
+		parents:  make(map[string]string),
+		children: make(map[string][]*asyncEvent),
+	}
+	for i := 0; i < workers; i++ {
+		a.workers.Add(1)
+		go a.work()
+	}
+	return a
+}
+
+func (a *asyncReporter) work() {
+	defer a.workers.Done()
+	for ev := range a.queue {
+		a.deliver(ev)
+	}
+}
+
+func (a *asyncReporter) deliver(ev *asyncEvent) {
+	defer func() {
+		ev.cancel()
+		close(ev.done)
+		a.resMu.Lock()
+		delete(a.inflight, ev)
+		a.resMu.Unlock()
+	}()
+	for _, d := range ev.deps {
+		<-d.done
+		if d.err != nil && !d.aborted {
+			// root cause is already collected, dependent events are skipped silently
+			ev.err = d.err
+			return
+		}
+	}
+	var resp interface{}
+	_, ev.err = a.c.report(ev.req.WithContext(ev.ctx), &resp, ev.id)
+	ev.aborted = ev.err != nil && ev.ctx.Err() != nil && a.ctx.Err() == nil
+	if ev.err != nil && !ev.aborted {
+		a.resMu.Lock()
+		a.errs = multierr.Append(a.errs, ev.err)
+		a.resMu.Unlock()
+	}
+}
+
+// enqueue must be called with a.mu held
+func (a *asyncReporter) enqueue(req *http.Request, id string, deps []*asyncEvent) (*asyncEvent, error) {
+	if a.closed {
+		return nil, ErrAsyncReporterClosed
+	}
+	ev := &asyncEvent{req: req, id: id, deps: deps, done: make(chan struct{})}
+	ev.ctx, ev.cancel = context.WithCancel(a.ctx)
+	a.resMu.Lock()
+	a.inflight[ev] = struct{}{}
+	a.resMu.Unlock()
+	a.queue <- ev
+	return ev, nil
+}
+
+// start enqueues launch or item start, parentId is empty for launch
+func (a *asyncReporter) start(id string, parentId string, req *http.Request) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var deps []*asyncEvent
+	if p, ok := a.starts[parentId]; ok {
+		deps = append(deps, p)
+	}
+	ev, err := a.enqueue(req, id, deps)
+	if err != nil {
+		return err
+	}
+	a.starts[id] = ev
+	a.parents[id] = parentId
+	a.children[parentId] = append(a.children[parentId], ev)
+	return nil
+}
+
+// finish enqueues item finish after its start and all events reported inside the item
+func (a *asyncReporter) finish(id string, req *http.Request) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var deps []*asyncEvent
+	if s, ok := a.starts[id]; ok {
+		deps = append(deps, s)
+	}
+	deps = append(deps, a.children[id]...)
+	ev, err := a.enqueue(req, "", deps)
+	if err != nil {
+		return err
+	}
+	parentId := a.parents[id]
+	a.children[parentId] = append(a.children[parentId], ev)
+	delete(a.starts, id)
+	delete(a.parents, id)
+	delete(a.children, id)
+	return nil
+}
+
+// finishLaunch enqueues launch finish after every event enqueued before
+func (a *asyncReporter) finishLaunch(req *http.Request) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, err := a.enqueue(req, "", a.inflightEvents())
+	if err != nil {
+		return err
+	}
+	a.starts = make(map[string]*asyncEvent)
+	a.parents = make(map[string]string)
+	a.children = make(map[string][]*asyncEvent)
+	return nil
+}
+
+// log enqueues log request after starts of all items it is attached to
+func (a *asyncReporter) log(itemIds []string, req *http.Request) (*asyncEvent, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var deps []*asyncEvent
+	for _, id := range itemIds {
+		if s, ok := a.starts[id]; ok {
+			deps = append(deps, s)
+		}
+	}
+	ev, err := a.enqueue(req, "", deps)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range itemIds {
+		a.children[id] = append(a.children[id], ev)
+	}
+	return ev, nil
+}
+
+// wait waits until event is delivered, delivery is aborted when ctx is done,
+// delivery errors are returned by flush as for other events
+func (a *asyncReporter) wait(ctx context.Context, ev *asyncEvent) error {
+	select {
+	case <-ev.done:
+		return nil
+	case <-ctx.Done():
+		ev.cancel()
+		<-ev.done
+		return ctx.Err()
+	}
+}
+
+func (a *asyncReporter) inflightEvents() []*asyncEvent {
+	a.resMu.Lock()
+	defer a.resMu.Unlock()
+	evs := make([]*asyncEvent, 0, len(a.inflight))
+	for ev := range a.inflight {
+		evs = append(evs, ev)
+	}
+	return evs
+}
+
+// flush waits until all events enqueued before the call are delivered, returns and resets collected errors
+func (a *asyncReporter) flush(ctx context.Context) error {
+	for _, ev := range a.inflightEvents() {
+		select {
+		case <-ev.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	a.resMu.Lock()
+	defer a.resMu.Unlock()
+	err := a.errs
+	a.errs = nil
+	return err
+}
+
+func (a *asyncReporter) close(ctx context.Context) error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	close(a.queue)
+	a.mu.Unlock()
+	err := a.flush(ctx)
+	if err != nil && err == ctx.Err() {
+		a.cancel()
+		return err
+	}
+	a.workers.Wait()
+	a.cancel()
+	return err
+}
@@ -0,0 +1,331 @@
+package rpgoclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"time"
+)
+
+// defaultLogBatchMaxBytes is below default Report Portal upload limit of 64MB
+const defaultLogBatchMaxBytes = 32 << 20
+
+// Attachment is a file attached to log message, it is referenced by LogPayload.File.Name.
+// Reader is streamed into request, if it is io.Seeker request can be retried
+type Attachment struct {
+	Name        string
+	ContentType string
+	Reader      io.Reader
+}
+
+func (c *Client) LogWithAttachment(itemId string, message string, level string, name string, contentType string, r io.Reader) (string, error) {
+	return c.LogWithAttachmentContext(context.Background(), itemId, message, level, name, contentType, r)
+}
+
+// LogWithAttachmentContext attaches log with file to test item by id
+func (c *Client) LogWithAttachmentContext(ctx context.Context, itemId string, message string, level string, name string, contentType string, r io.Reader) (string, error) {
+	return c.logAttachment(ctx, c.launchId(), itemId, message, level, Attachment{Name: name, ContentType: contentType, Reader: r})
+}
+
+func (c *Client) LogBatchWithAttachments(messages []LogPayload, attachments []Attachment) error {
+	return c.LogBatchWithAttachmentsContext(context.Background(), messages, attachments)
+}
+
+// LogBatchWithAttachmentsContext sends log messages with files in streamed multipart requests,
+// split by WithLogBatchMaxBytes, messages reference attachments by LogPayload.File.Name
+func (c *Client) LogBatchWithAttachmentsContext(ctx context.Context, messages []LogPayload, attachments []Attachment) error {
+	c.scanLogs(messages)
+	return c.logBatch(ctx, c.launchId(), messages, attachments)
+}
+
+// LogWithAttachment attaches log with file to the item
+func (i *Item) LogWithAttachment(ctx context.Context, message string, level string, name string, contentType string, r io.Reader) (string, error) {
+	return i.launch.c.logAttachment(ctx, i.launch.Id, i.Id, message, level, Attachment{Name: name, ContentType: contentType, Reader: r})
+}
+
+func (c *Client) logAttachment(ctx context.Context, launchId string, itemId string, message string, level string, a Attachment) (string, error) {
+	if c.scanner != nil {
+		c.scanner.scan(itemId, message)
+	}
+	p := LogPayload{
+		ItemId:  itemId,
+		Time:    time.Now().Format(time.RFC3339),
+		Message: message,
+		Level:   level,
+		File:    &LogFile{Name: a.Name},
+	}
+	c.l.Debugf("attaching log to test item: %s, msg: %s, lvl: %s, file: %s", p.ItemId, p.Message, p.Level, a.Name)
+	resp, err := c.logMultipart(ctx, launchId, []LogPayload{p}, []Attachment{a})
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Responses) == 0 {
+		return "", nil
+	}
+	return resp.Responses[0].Id, nil
+}
+
+// logBatchChunk is a part of log batch sent in one request
+type logBatchChunk struct {
+	messages    []LogPayload
+	attachments []Attachment
+	size        int64
+}
+
+func (c *Client) logBatch(ctx context.Context, launchId string, messages []LogPayload, attachments []Attachment) error {
+	for _, chunk := range splitLogBatch(messages, attachments, c.logBatchMaxBytes) {
+		resp, err := c.logMultipart(ctx, launchId, chunk.messages, chunk.attachments)
+		if err != nil {
+			return err
+		}
+		c.l.Debugf("log batch attached: %d messages, %d bytes", len(chunk.messages), chunk.size)
+		for _, r := range resp.Responses {
+			c.l.Debugf("log batch attached id: %s", r.Id)
+		}
+	}
+	return nil
+}
+
+// splitLogBatch groups messages with attachments they reference into chunks of at most maxBytes,
+// message which exceeds the limit alone is sent in its own chunk, attachments of unknown size fill the whole chunk
+func splitLogBatch(messages []LogPayload, attachments []Attachment, maxBytes int64) []logBatchChunk {
+	byName := make(map[string][]Attachment)
+	for _, a := range attachments {
+		byName[a.Name] = append(byName[a.Name], a)
+	}
+	var chunks []logBatchChunk
+	var cur logBatchChunk
+	add := func(msgs []LogPayload, atts []Attachment, size int64) {
+		if len(cur.messages)+len(cur.attachments) > 0 && cur.size+size > maxBytes {
+			chunks = append(chunks, cur)
+			cur = logBatchChunk{}
+		}
+		cur.messages = append(cur.messages, msgs...)
+		cur.attachments = append(cur.attachments, atts...)
+		cur.size += size
+	}
+	for _, m := range messages {
+		b, _ := json.Marshal(m)
+		size := int64(len(b))
+		var atts []Attachment
+		if m.File != nil {
+			atts = byName[m.File.Name]
+			delete(byName, m.File.Name)
+		}
+		for _, a := range atts {
+			size += attachmentSize(a, maxBytes)
+		}
+		add([]LogPayload{m}, atts, size)
+	}
+	// attachments not referenced by messages are sent as is
+	for _, a := range attachments {
+		if _, ok := byName[a.Name]; ok {
+			add(nil, []Attachment{a}, attachmentSize(a, maxBytes))
+		}
+	}
+	if len(cur.messages)+len(cur.attachments) > 0 {
+		chunks = append(chunks, cur)
+	}
+	return chunks
+}
+
+// attachmentSize returns size of attachment left to read, unknown size is reported as maxBytes
+func attachmentSize(a Attachment, maxBytes int64) int64 {
+	switch r := a.Reader.(type) {
+	case interface{ Len() int }:
+		return int64(r.Len())
+	case io.Seeker:
+		cur, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return maxBytes
+		}
+		end, err := r.Seek(0, io.SeekEnd)
+		if err != nil {
+			return maxBytes
+		}
+		if _, err := r.Seek(cur, io.SeekStart); err != nil {
+			return maxBytes
+		}
+		return end - cur
+	}
+	return maxBytes
+}
+
+func (c *Client) logMultipart(ctx context.Context, launchId string, messages []LogPayload, attachments []Attachment) (LogBatchResponse, error) {
+	payload, err := c.logBatchPayload(launchId, messages)
+	if err != nil {
+		return LogBatchResponse{}, err
+	}
+	body, err := newMultipartBody(payload, attachments)
+	if err != nil {
+		return LogBatchResponse{}, err
+	}
+	req, err := c.multipartRequest(ctx, fmt.Sprintf("%s/%s/log", c.ApiURL, c.Project), body)
+	if err != nil {
+		return LogBatchResponse{}, err
+	}
+	// attachments are not read after return, so caller can close them
+	defer body.close()
+	var respBody LogBatchResponse
+	if c.async != nil {
+		itemIds := make([]string, 0, len(messages))
+		for _, m := range messages {
+			itemIds = append(itemIds, m.ItemId)
+		}
+		var ev *asyncEvent
+		if ev, err = c.async.log(itemIds, req); err == nil && len(attachments) > 0 {
+			// attachments are streamed by async worker, so it blocks until the log is delivered
+			err = c.async.wait(ctx, ev)
+		}
+	} else {
+		_, err = c.report(req, &respBody, "")
+	}
+	return respBody, err
+}
+
+// multipartRequest returns POST request of body, body without attachments is small, so it is buffered in memory,
+// otherwise it is streamed
+func (c *Client) multipartRequest(ctx context.Context, path string, body *multipartBody) (*http.Request, error) {
+	if len(body.attachments) == 0 {
+		var buf bytes.Buffer
+		if err := body.write(&buf); err != nil {
+			return nil, err
+		}
+		return c.newRequest(ctx, "POST", path, bytes.NewReader(buf.Bytes()), body.contentType())
+	}
+	r, err := body.open()
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.newRequest(ctx, "POST", path, r, body.contentType())
+	if err != nil {
+		body.close()
+		return nil, err
+	}
+	if body.rewindable {
+		req.GetBody = body.open
+	}
+	return req, nil
+}
+
+// multipartBody streams json_request_part and attachments through a pipe, so files are never buffered in memory
+type multipartBody struct {
+	boundary    string
+	payload     []byte
+	attachments []Attachment
+	// rewindable is set when all attachments are seekable, offsets are their start positions
+	rewindable bool
+	offsets    []int64
+	// pr and written belong to the last opened reader, its writer must stop before attachments are rewound
+	pr      *io.PipeReader
+	written chan struct{}
+}
+
+// newMultipartBody returns body of payload and attachments, nil payload is not sent
+func newMultipartBody(payload interface{}, attachments []Attachment) (*multipartBody, error) {
+	body := &multipartBody{
+		boundary:    multipart.NewWriter(ioutil.Discard).Boundary(),
+		attachments: attachments,
+		rewindable:  true,
+	}
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		body.payload = b
+	}
+	for _, a := range attachments {
+		s, ok := a.Reader.(io.Seeker)
+		if !ok {
+			body.rewindable = false
+			break
+		}
+		off, err := s.Seek(0, io.SeekCurrent)
+		if err != nil {
+			body.rewindable = false
+			break
+		}
+		body.offsets = append(body.offsets, off)
+	}
+	return body, nil
+}
+
+func (b *multipartBody) contentType() string {
+	return mime.FormatMediaType("multipart/form-data", map[string]string{"boundary": b.boundary})
+}
+
+// open returns reader of the body, every next call rewinds attachments to their start positions
+func (b *multipartBody) open() (io.ReadCloser, error) {
+	if b.pr != nil {
+		if !b.rewindable {
+			return nil, ErrBodyNotRewindable
+		}
+		b.pr.Close()
+		<-b.written
+		for i, a := range b.attachments {
+			if _, err := a.Reader.(io.Seeker).Seek(b.offsets[i], io.SeekStart); err != nil {
+				return nil, err
+			}
+		}
+	}
+	pr, pw := io.Pipe()
+	written := make(chan struct{})
+	b.pr, b.written = pr, written
+	go func() {
+		defer close(written)
+		pw.CloseWithError(b.write(pw))
+	}()
+	return pr, nil
+}
+
+// close stops writer of the last opened reader
+func (b *multipartBody) close() {
+	if b.pr != nil {
+		b.pr.Close()
+		<-b.written
+	}
+}
+
+func (b *multipartBody) write(w io.Writer) error {
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(b.boundary); err != nil {
+		return err
+	}
+	if b.payload != nil {
+		mh := make(textproto.MIMEHeader)
+		mh.Set("Content-Type", "application/json")
+		mh.Set("Content-Disposition", `form-data; name="json_request_part"`)
+		pw, err := mw.CreatePart(mh)
+		if err != nil {
+			return err
+		}
+		if _, err := pw.Write(b.payload); err != nil {
+			return err
+		}
+	}
+	for _, a := range b.attachments {
+		fh := make(textproto.MIMEHeader)
+		contentType := a.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		fh.Set("Content-Type", contentType)
+		fh.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": "file", "filename": a.Name}))
+		fw, err := mw.CreatePart(fh)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(fw, a.Reader); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
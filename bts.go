@@ -0,0 +1,178 @@
+package rpgoclient
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// BTS is bug tracking system integrated with Report Portal, it parses ticket ids into issues to link
+type BTS interface {
+	// ParseTicket returns issue with ticket id, bts project, bts url and ticket url,
+	// ok is false if ticketId is not a ticket of the bts
+	ParseTicket(ticketId string) (issue Issue, ok bool)
+}
+
+var (
+	jiraTicket    = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9_]*)-(\d+)$`)
+	repoTicket    = regexp.MustCompile(`^([\w.-]+(?:/[\w.-]+)+)?#(\d+)$`)
+	redmineTicket = regexp.MustCompile(`^#?(\d+)$`)
+)
+
+// JiraBTS parses Jira tickets, e.g. PROJ-123, bts project is lowercased project key
+type JiraBTS struct {
+	Url string
+}
+
+func (b JiraBTS) ParseTicket(ticketId string) (Issue, bool) {
+	m := jiraTicket.FindStringSubmatch(ticketId)
+	if m == nil {
+		return Issue{}, false
+	}
+	return Issue{
+		BtsProject: strings.ToLower(m[1]),
+		BtsUrl:     b.Url,
+		TicketId:   ticketId,
+		Url:        fmt.Sprintf("%s/browse/%s", strings.TrimRight(b.Url, "/"), ticketId),
+	}, true
+}
+
+// GitHubBTS parses GitHub issues, e.g. owner/repo#123, or #123 of Repo,
+// empty Url is https://github.com
+type GitHubBTS struct {
+	Url  string
+	Repo string
+}
+
+func (b GitHubBTS) ParseTicket(ticketId string) (Issue, bool) {
+	return parseRepoTicket(ticketId, b.Url, "https://github.com", b.Repo, "issues")
+}
+
+// GitLabBTS parses GitLab issues, e.g. group/project#123, or #123 of Project,
+// empty Url is https://gitlab.com
+type GitLabBTS struct {
+	Url     string
+	Project string
+}
+
+func (b GitLabBTS) ParseTicket(ticketId string) (Issue, bool) {
+	return parseRepoTicket(ticketId, b.Url, "https://gitlab.com", b.Project, "-/issues")
+}
+
+// parseRepoTicket parses repo#number ticket, repo is defaultRepo if ticket has only number
+func parseRepoTicket(ticketId string, btsUrl string, defaultUrl string, defaultRepo string, issuesPath string) (Issue, bool) {
+	m := repoTicket.FindStringSubmatch(ticketId)
+	if m == nil {
+		return Issue{}, false
+	}
+	repo := m[1]
+	if repo == "" {
+		repo = defaultRepo
+	}
+	if repo == "" {
+		return Issue{}, false
+	}
+	if btsUrl == "" {
+		btsUrl = defaultUrl
+	}
+	btsUrl = strings.TrimRight(btsUrl, "/")
+	return Issue{
+		BtsProject: repo,
+		BtsUrl:     btsUrl,
+		TicketId:   fmt.Sprintf("%s#%s", repo, m[2]),
+		Url:        fmt.Sprintf("%s/%s/%s/%s", btsUrl, repo, issuesPath, m[2]),
+	}, true
+}
+
+// RedmineBTS parses numeric Redmine issues, e.g. 123 or #123, of Project
+type RedmineBTS struct {
+	Url     string
+	Project string
+}
+
+func (b RedmineBTS) ParseTicket(ticketId string) (Issue, bool) {
+	m := redmineTicket.FindStringSubmatch(ticketId)
+	if m == nil {
+		return Issue{}, false
+	}
+	return Issue{
+		BtsProject: b.Project,
+		BtsUrl:     b.Url,
+		TicketId:   m[1],
+		Url:        fmt.Sprintf("%s/issues/%s", strings.TrimRight(b.Url, "/"), m[1]),
+	}, true
+}
+
+// WithBTS sets bug tracking systems tickets are parsed with, the first one which parses ticket is used,
+// by default tickets are parsed as Jira tickets of btsUrl given to New
+func WithBTS(bts ...BTS) func(client *Client) error {
+	return func(client *Client) error {
+		client.bts = bts
+		return nil
+	}
+}
+
+// ParseTicket parses ticket id with bug tracking systems of the client
+func (c *Client) ParseTicket(ticketId string) (Issue, error) {
+	bts := c.bts
+	if bts == nil && c.BTSUrl != "" {
+		bts = []BTS{JiraBTS{Url: c.BTSUrl}}
+	}
+	for _, b := range bts {
+		if issue, ok := b.ParseTicket(ticketId); ok {
+			return issue, nil
+		}
+	}
+	return Issue{}, fmt.Errorf("%w: %s", ErrUnknownTicket, ticketId)
+}
+
+// ticketIssue returns parsed ticket, without WithBTS unknown tickets are linked as Jira tickets to bts url of the client,
+// with WithBTS they fail with ErrUnknownTicket
+func (c *Client) ticketIssue(ticketId string) (Issue, error) {
+	issue, err := c.ParseTicket(ticketId)
+	if err != nil && c.bts == nil {
+		return Issue{
+			BtsProject: strings.ToLower(strings.Split(ticketId, "-")[0]),
+			BtsUrl:     c.BTSUrl,
+			TicketId:   ticketId,
+		}, nil
+	}
+	return issue, err
+}
+
+type unlinkIssuesPayload struct {
+	TestItemIds []int    `json:"testItemIds"`
+	TicketIds   []string `json:"ticketIds"`
+}
+
+// LinkIssues links tickets to items by numeric ids, issue without SubmitDate is submitted now
+func (c *Client) LinkIssues(ctx context.Context, itemIds []int, issues []Issue) (string, error) {
+	p := LinkIssue{Issues: make([]Issue, 0, len(issues)), TestItemIds: itemIds}
+	for _, issue := range issues {
+		if issue.SubmitDate == 0 {
+			issue.SubmitDate = time.Now().UnixNano() / int64(time.Millisecond)
+		}
+		p.Issues = append(p.Issues, issue)
+	}
+	c.l.Debugf("linking %d issues to items: %v", len(issues), itemIds)
+	var respBody FinishTestItemResponse
+	if err := c.send(ctx, "PUT", fmt.Sprintf("%s/%s/item/issue/link", c.apiV1URL(), c.Project), p, &respBody); err != nil {
+		return "", err
+	}
+	c.l.Debugf("linked item issues: %s", respBody.message())
+	return respBody.message(), nil
+}
+
+// UnlinkIssues unlinks tickets by ticket ids from items by numeric ids
+func (c *Client) UnlinkIssues(ctx context.Context, itemIds []int, ticketIds []string) (string, error) {
+	c.l.Debugf("unlinking issues %v from items: %v", ticketIds, itemIds)
+	var respBody FinishTestItemResponse
+	p := unlinkIssuesPayload{TestItemIds: itemIds, TicketIds: ticketIds}
+	if err := c.send(ctx, "PUT", fmt.Sprintf("%s/%s/item/issue/unlink", c.apiV1URL(), c.Project), p, &respBody); err != nil {
+		return "", err
+	}
+	c.l.Debugf("unlinked item issues: %s", respBody.message())
+	return respBody.message(), nil
+}
@@ -2,22 +2,24 @@ package rpgoclient
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"github.com/golang-collections/collections/stack"
+	"go.uber.org/multierr"
 	"go.uber.org/zap"
 	"io"
 	"io/ioutil"
 	"log"
-	"mime/multipart"
 	"net/http"
 	"net/http/httputil"
-	"net/textproto"
 	"net/url"
-	"strings"
+	"sync"
 	"time"
 )
 
+// Client reports launches, items and logs to Report Portal project.
+// Methods taking ctx stop the request and its retries when ctx is done, except requests delivered
+// by WithAsync workers, their pairs without Context suffix use context.Background()
 type Client struct {
 	BaseURL   *url.URL
 	ApiURL    string
@@ -26,12 +28,25 @@ type Client struct {
 	Token     string
 	UserAgent string
 
-	Stack    *stack.Stack
-	LaunchId string
-	Retries  int
+	Retries    int
+	APIVersion int
 
-	httpClient *http.Client
-	l          *zap.SugaredLogger
+	mu sync.Mutex
+	// launch and stack are state of the stack based api, stack holds handles of started items
+	// with nil for the launch at the bottom, use Launch and Item handles for parallel reporting
+	launch      *Launch
+	stack       []*Item
+	httpClient  *http.Client
+	retryPolicy RetryPolicy
+	// logBatchMaxBytes bounds size of one log batch request
+	logBatchMaxBytes int64
+	async            *asyncReporter
+	asyncWorkers     int
+	logBuf           *logBuffer
+	bts              []BTS
+	scanner          *ticketScanner
+	spool            *spool
+	l                *zap.SugaredLogger
 }
 
 func New(baseUrl string, project string, token string, btsUrl string, dumptransport bool, options ...func(*Client) error) *Client {
@@ -46,10 +61,11 @@ func New(baseUrl string, project string, token string, btsUrl string, dumptransp
 	c.Project = project
 	c.Token = token
 	c.ApiURL = "/api/v1"
+	c.APIVersion = 1
 	c.Retries = 3
+	c.retryPolicy = DefaultRetryPolicy()
+	c.logBatchMaxBytes = defaultLogBatchMaxBytes
 	c.l = NewLogger("info")
-	c.Stack = stack.New()
-	c.LaunchId = ""
 	c.BTSUrl = btsUrl
 
 	for _, op := range options {
@@ -58,6 +74,15 @@ func New(baseUrl string, project string, token string, btsUrl string, dumptransp
 			c.l.Fatalf("option failed: %s", err)
 		}
 	}
+	if c.asyncWorkers > 0 {
+		if c.APIVersion != 2 {
+			c.l.Fatalf("option failed: %s", ErrAsyncRequiresV2)
+		}
+		c.async = newAsyncReporter(c, c.asyncWorkers)
+	}
+	if c.logBuf != nil {
+		c.logBuf.startTicker()
+	}
 	return c
 }
 
@@ -66,7 +91,22 @@ func (c *Client) GetBaseUrl() string {
 }
 
 func (c *Client) GetLaunchId() string {
-	return c.LaunchId
+	return c.launchId()
+}
+
+// CurrentLaunch returns handle of the launch started with StartLaunch, nil if there is no one,
+// items started with StartTestItem are its items
+func (c *Client) CurrentLaunch() *Launch {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.launch
+}
+
+// StackLen returns depth of the stack based api, started launch counts as one
+func (c *Client) StackLen() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.stack)
 }
 
 func (c *Client) GetProject() string {
@@ -105,184 +145,406 @@ func WithVerbosity(verbosity string) func(client *Client) error {
 func WithRetries(retries int) func(client *Client) error {
 	return func(c *Client) error {
 		c.Retries = retries
+		c.retryPolicy.MaxRetries = retries
+		return nil
+	}
+}
+
+// WithLogBatchMaxBytes bounds size of one log batch request, batches with messages and attachments
+// exceeding the limit are split into several requests
+func WithLogBatchMaxBytes(maxBytes int64) func(client *Client) error {
+	return func(c *Client) error {
+		if maxBytes <= 0 {
+			return fmt.Errorf("log batch max bytes must be positive, got %d", maxBytes)
+		}
+		c.logBatchMaxBytes = maxBytes
+		return nil
+	}
+}
+
+// WithAPIVersion selects Report Portal reporting API version, 1 for legacy servers or 2 for asynchronous reporting
+func WithAPIVersion(version int) func(client *Client) error {
+	return func(c *Client) error {
+		if version != 1 && version != 2 {
+			return ErrUnsupportedAPIVersion
+		}
+		c.APIVersion = version
+		c.ApiURL = fmt.Sprintf("/api/v%d", version)
 		return nil
 	}
 }
 
+// WithAsync enables background reporting with given number of workers, requires v2 api,
+// reporting methods return right after request is enqueued, use Flush or Close to wait for delivery.
+// Logs with attachments return after delivery, so attachment readers are not used after return
+func WithAsync(workers int) func(client *Client) error {
+	return func(c *Client) error {
+		if workers < 1 {
+			return fmt.Errorf("async workers must be positive, got %d", workers)
+		}
+		c.asyncWorkers = workers
+		return nil
+	}
+}
+
+// Flush sends buffered logs, waits until all asynchronously reported events are delivered
+// and returns errors collected since last flush
+func (c *Client) Flush(ctx context.Context) error {
+	var errs error
+	if c.logBuf != nil {
+		errs = multierr.Append(c.logBuf.takeErrors(), c.logBuf.flush(ctx, ""))
+	}
+	if c.async != nil {
+		errs = multierr.Append(errs, c.async.flush(ctx))
+	}
+	if c.scanner != nil {
+		errs = multierr.Append(errs, c.scanner.flush(ctx))
+	}
+	return errs
+}
+
+// Close sends buffered logs, flushes asynchronously reported events and stops background workers
+func (c *Client) Close(ctx context.Context) error {
+	var errs error
+	if c.logBuf != nil {
+		c.logBuf.stopTicker()
+		errs = multierr.Append(c.logBuf.takeErrors(), c.logBuf.flush(ctx, ""))
+	}
+	if c.async != nil {
+		errs = multierr.Append(errs, c.async.close(ctx))
+	}
+	if c.scanner != nil {
+		errs = multierr.Append(errs, c.scanner.flush(ctx))
+	}
+	return errs
+}
+
 func (c *Client) StartLaunch(name string, description string, startTimeStringRFC3339 string, tags []string, mode string) (StartLaunchResponse, error) {
+	return c.StartLaunchContext(context.Background(), name, description, startTimeStringRFC3339, tags, mode)
+}
+
+// StartLaunchContext starts launch
+func (c *Client) StartLaunchContext(ctx context.Context, name string, description string, startTimeStringRFC3339 string, tags []string, mode string) (StartLaunchResponse, error) {
+	respBody, err := c.startLaunch(ctx, name, description, startTimeStringRFC3339, tags, mode)
+	if err != nil {
+		return StartLaunchResponse{}, err
+	}
+	c.mu.Lock()
+	c.launch = c.LaunchHandle(respBody.Id)
+	c.stack = append(c.stack, nil)
+	c.mu.Unlock()
+	return respBody, err
+}
+
+func (c *Client) FinishLaunch(status string, endTimeStringRFC3339 string) (FinishLaunchResponse, error) {
+	return c.FinishLaunchContext(context.Background(), status, endTimeStringRFC3339)
+}
+
+// FinishLaunchContext finishes current launch
+func (c *Client) FinishLaunchContext(ctx context.Context, status string, endTimeStringRFC3339 string) (FinishLaunchResponse, error) {
+	launch := c.CurrentLaunch()
+	if launch == nil {
+		return FinishLaunchResponse{}, ErrNoLaunchId
+	}
+	respBody, err := launch.Finish(ctx, status, endTimeStringRFC3339)
+	if !finishSent(err) {
+		return respBody, err
+	}
+	c.mu.Lock()
+	if len(c.stack) > 0 {
+		c.stack = c.stack[:len(c.stack)-1]
+	}
+	c.mu.Unlock()
+	return respBody, err
+}
+
+func (c *Client) StartTestItem(name string, itemType string, startTimeStringRFC3339 string, description string, tags []string, parameters []map[string]string) (StartTestItemResponse, error) {
+	return c.StartTestItemContext(context.Background(), name, itemType, startTimeStringRFC3339, description, tags, parameters)
+}
+
+// StartTestItemContext starts test item as a child of the item on top of the stack
+func (c *Client) StartTestItemContext(ctx context.Context, name string, itemType string, startTimeStringRFC3339 string, description string, tags []string, parameters []map[string]string) (StartTestItemResponse, error) {
+	c.mu.Lock()
+	launch, parent := c.launch, c.top()
+	c.mu.Unlock()
+	if launch == nil {
+		return StartTestItemResponse{}, ErrNoLaunchId
+	}
+	it, respBody, err := launch.startItem(ctx, parent, name, itemType, startTimeStringRFC3339, description, tags, parameters)
+	if err != nil {
+		return StartTestItemResponse{}, err
+	}
+	c.mu.Lock()
+	c.stack = append(c.stack, it)
+	c.mu.Unlock()
+	return respBody, err
+}
+
+func (c *Client) StartTestItemId(parentItemId string, name string, itemType string, startTimeStringRFC3339 string, description string, tags []string, parameters []map[string]string) (StartTestItemResponse, error) {
+	return c.StartTestItemIdContext(context.Background(), parentItemId, name, itemType, startTimeStringRFC3339, description, tags, parameters)
+}
+
+// StartTestItemIdContext starts test item as a child of parentItemId without touching the stack
+func (c *Client) StartTestItemIdContext(ctx context.Context, parentItemId string, name string, itemType string, startTimeStringRFC3339 string, description string, tags []string, parameters []map[string]string) (StartTestItemResponse, error) {
+	return c.startItem(ctx, c.launchId(), parentItemId, name, itemType, startTimeStringRFC3339, description, tags, parameters)
+}
+
+func (c *Client) FinishTestItem(status string, endTimeStringRFC3339 string, issue map[string]interface{}) (string, error) {
+	return c.FinishTestItemContext(context.Background(), status, endTimeStringRFC3339, issue)
+}
+
+// FinishTestItemContext finishes the item on top of the stack
+func (c *Client) FinishTestItemContext(ctx context.Context, status string, endTimeStringRFC3339 string, issue map[string]interface{}) (string, error) {
+	it := c.pop()
+	if it == nil {
+		return "", ErrNoTestItem
+	}
+	return it.Finish(ctx, status, endTimeStringRFC3339, issue)
+}
+
+func (c *Client) FinishTestItemId(id string, status string, endTimeStringRFC3339 string, issue map[string]interface{}) (string, error) {
+	return c.FinishTestItemIdContext(context.Background(), id, status, endTimeStringRFC3339, issue)
+}
+
+// FinishTestItemIdContext finishes test item by id
+func (c *Client) FinishTestItemIdContext(ctx context.Context, id string, status string, endTimeStringRFC3339 string, issue map[string]interface{}) (string, error) {
+	return c.finishItem(ctx, c.launchId(), id, status, endTimeStringRFC3339, issue)
+}
+
+func (c *Client) LinkIssue(itemId int, ticketId string, url string) (string, error) {
+	return c.LinkIssueContext(context.Background(), itemId, ticketId, url)
+}
+
+// LinkIssueContext links bts ticket to test item, ticket is parsed with WithBTS bug tracking systems,
+// url overrides parsed ticket url
+func (c *Client) LinkIssueContext(ctx context.Context, itemId int, ticketId string, url string) (string, error) {
+	issue, err := c.ticketIssue(ticketId)
+	if err != nil {
+		return "", err
+	}
+	if url != "" {
+		issue.Url = url
+	}
+	return c.LinkIssues(ctx, []int{itemId}, []Issue{issue})
+}
+
+func (c *Client) LogBatch(messages []LogPayload) error {
+	return c.LogBatchContext(context.Background(), messages)
+}
+
+// LogBatchContext sends log messages in streamed multipart requests, split by WithLogBatchMaxBytes
+func (c *Client) LogBatchContext(ctx context.Context, messages []LogPayload) error {
+	c.scanLogs(messages)
+	return c.logBatch(ctx, c.launchId(), messages, nil)
+}
+
+func (c *Client) Log(message string, level string) (string, error) {
+	return c.LogContext(context.Background(), message, level)
+}
+
+// LogContext attaches log to the item on top of the stack
+func (c *Client) LogContext(ctx context.Context, message string, level string) (string, error) {
+	c.mu.Lock()
+	it := c.top()
+	c.mu.Unlock()
+	if it == nil {
+		return "", ErrLogNotAttachableToLaunch
+	}
+	return it.Log(ctx, message, level)
+}
+
+func (c *Client) LogId(id string, message string, level string) (string, error) {
+	return c.LogIdContext(context.Background(), id, message, level)
+}
+
+// LogIdContext attaches log to test item by id
+func (c *Client) LogIdContext(ctx context.Context, id string, message string, level string) (string, error) {
+	return c.logItem(ctx, c.launchId(), id, message, level)
+}
+
+func (c *Client) GetItemIdByUUID(uuid string) (GetItemResponse, error) {
+	return c.GetItemIdByUUIDContext(context.Background(), uuid)
+}
+
+// GetItemIdByUUIDContext gets item id by uuid
+func (c *Client) GetItemIdByUUIDContext(ctx context.Context, uuid string) (GetItemResponse, error) {
+	u := fmt.Sprintf("%s/%s/item/%s", c.ApiURL, c.Project, uuid)
+	if c.APIVersion == 2 {
+		u = fmt.Sprintf("%s/%s/item/uuid/%s", c.apiV1URL(), c.Project, uuid)
+	}
+	req, err := c.newRequest(ctx, "GET", u, nil, "")
+	if err != nil {
+		return GetItemResponse{}, err
+	}
+	var respBody GetItemResponse
+	_, err = c.do(req, &respBody)
+	if err != nil {
+		return GetItemResponse{}, err
+	}
+	c.l.Debugf("get item id by uuid: %s\n", respBody)
+	return respBody, err
+}
+
+// launchId returns id of the launch started with StartLaunch
+func (c *Client) launchId() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.launch == nil {
+		return ""
+	}
+	return c.launch.Id
+}
+
+// top returns item on top of the stack, nil for the launch or empty stack, c.mu must be held
+func (c *Client) top() *Item {
+	if len(c.stack) == 0 {
+		return nil
+	}
+	return c.stack[len(c.stack)-1]
+}
+
+// pop removes item from top of the stack, the launch is not removed
+func (c *Client) pop() *Item {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	it := c.top()
+	if it != nil {
+		c.stack = c.stack[:len(c.stack)-1]
+	}
+	return it
+}
+
+func (c *Client) startLaunch(ctx context.Context, name string, description string, startTimeStringRFC3339 string, tags []string, mode string) (StartLaunchResponse, error) {
 	var startTime string
 	if startTimeStringRFC3339 != "" {
 		startTime = startTimeStringRFC3339
 	} else {
 		startTime = time.Now().Format(time.RFC3339)
 	}
-	p := StartLaunchPayload{
-		Name:        name,
-		StartTime:   startTime,
-		Description: description,
-		Tags:        tags,
-		Mode:        mode,
+	launchUuid := c.newClientId()
+	p, err := c.startLaunchPayload(launchUuid, name, description, startTime, tags, mode)
+	if err != nil {
+		return StartLaunchResponse{}, err
 	}
-	req, err := c.newRequest("POST", fmt.Sprintf("%s/%s/launch", c.ApiURL, c.Project), p, "application/json")
+	req, err := c.newRequest(ctx, "POST", fmt.Sprintf("%s/%s/launch", c.ApiURL, c.Project), p, "application/json")
 	if err != nil {
 		return StartLaunchResponse{}, err
 	}
 	dump, err := httputil.DumpRequest(req, true)
 	c.l.Infof("request: %s", dump)
 	var respBody StartLaunchResponse
-	_, err = c.do(req, &respBody)
+	var spooled bool
+	launchId := placeholderId(launchUuid)
+	if c.async != nil {
+		err = c.async.start(launchUuid, "", req)
+	} else {
+		spooled, err = c.report(req, &respBody, launchId)
+	}
 	if err != nil {
 		return StartLaunchResponse{}, err
 	}
-	if respBody.Id != "" {
-		c.LaunchId = respBody.Id
+	if launchUuid != "" || spooled {
+		respBody.Id = launchId
 	}
-	c.Stack.Push(nil)
-	c.LaunchId = respBody.Id
 	c.l.Debugf("created new test launch: %s", respBody.Id)
 	return respBody, err
 }
 
-func (c *Client) FinishLaunch(status string, endTimeStringRFC3339 string) (FinishLaunchResponse, error) {
+func (c *Client) finishLaunch(ctx context.Context, launchId string, status string, endTimeStringRFC3339 string) (FinishLaunchResponse, error) {
+	var flushErr error
+	if c.logBuf != nil {
+		if err := c.logBuf.flush(ctx, launchId); err != nil {
+			flushErr = &logsNotSentError{err: err}
+		}
+	}
 	var endTime string
 	if endTimeStringRFC3339 != "" {
 		endTime = endTimeStringRFC3339
 	} else {
 		endTime = time.Now().Format(time.RFC3339)
 	}
-	p := FinishLaunchPayload{
-		Status:  status,
-		EndTime: endTime,
-	}
-	if c.LaunchId == "" {
-		return FinishLaunchResponse{}, noLaunchIdErr
-	}
-	req, err := c.newRequest("PUT", fmt.Sprintf("%s/%s/launch/%s/finish", c.ApiURL, c.Project, c.LaunchId), p, "application/json")
+	p, err := c.finishLaunchPayload(status, endTime)
 	if err != nil {
-		return FinishLaunchResponse{}, err
+		return FinishLaunchResponse{}, multierr.Append(flushErr, err)
 	}
-	var respBody FinishLaunchResponse
-	_, err = c.do(req, &respBody)
+	req, err := c.newRequest(ctx, "PUT", fmt.Sprintf("%s/%s/launch/%s/finish", c.ApiURL, c.Project, launchId), p, "application/json")
 	if err != nil {
-		return respBody, err
-	}
-	if c.Stack.Len() >= 1 {
-		c.Stack.Pop()
+		return FinishLaunchResponse{}, multierr.Append(flushErr, err)
 	}
-	c.l.Debugf("launch finished: %s", c.LaunchId)
-	return respBody, err
-}
-
-func (c *Client) StartTestItem(name string, itemType string, startTimeStringRFC3339 string, description string, tags []string, parameters []map[string]string) (StartTestItemResponse, error) {
-	var startTime string
-	if startTimeStringRFC3339 != "" {
-		startTime = startTimeStringRFC3339
-	} else {
-		startTime = time.Now().Format(time.RFC3339)
-	}
-	p := StartTestItemPayload{
-		Name:        name,
-		StartTime:   startTime,
-		Description: description,
-		Tags:        tags,
-		LaunchId:    c.LaunchId,
-		Type:        itemType,
-		Parameters:  parameters,
-	}
-	c.l.Debugf("starting test item of type: %s", p.Type)
-	var u string
-	parentItemId := c.Stack.Peek()
-	if parentItemId != nil {
-		u = fmt.Sprintf("%s/%s/item/%s", c.ApiURL, c.Project, parentItemId)
+	var respBody FinishLaunchResponse
+	if c.async != nil {
+		respBody.Id = launchId
+		err = c.async.finishLaunch(req)
 	} else {
-		u = fmt.Sprintf("%s/%s/item", c.ApiURL, c.Project)
+		_, err = c.report(req, &respBody, "")
 	}
-	req, err := c.newRequest("POST", u, p, "application/json")
 	if err != nil {
-		return StartTestItemResponse{}, err
-	}
-	var respBody StartTestItemResponse
-	_, err = c.do(req, &respBody)
-	if err != nil {
-		return StartTestItemResponse{}, err
+		return respBody, multierr.Append(flushErr, err)
 	}
-	c.Stack.Push(respBody.Id)
-	c.l.Debugf("started test item: %s", respBody.Id)
-	return respBody, err
+	c.l.Debugf("launch finished: %s", launchId)
+	return respBody, flushErr
 }
 
-func (c *Client) StartTestItemId(parentItemId string, name string, itemType string, startTimeStringRFC3339 string, description string, tags []string, parameters []map[string]string) (StartTestItemResponse, error) {
+// startItem starts test item in launch, item is started as a child of parentItemId if it's not empty
+func (c *Client) startItem(ctx context.Context, launchId string, parentItemId string, name string, itemType string, startTimeStringRFC3339 string, description string, tags []string, parameters []map[string]string) (StartTestItemResponse, error) {
 	var startTime string
 	if startTimeStringRFC3339 != "" {
 		startTime = startTimeStringRFC3339
 	} else {
 		startTime = time.Now().Format(time.RFC3339)
 	}
-	p := StartTestItemPayload{
-		Name:        name,
-		StartTime:   startTime,
-		Description: description,
-		Tags:        tags,
-		LaunchId:    c.LaunchId,
-		Type:        itemType,
-		Parameters:  parameters,
+	itemUuid := c.newClientId()
+	p, err := c.startTestItemPayload(itemUuid, launchId, name, itemType, startTime, description, tags, parameters)
+	if err != nil {
+		return StartTestItemResponse{}, err
 	}
-	c.l.Debugf("starting test item of type: %s", p.Type)
+	c.l.Debugf("starting test item of type: %s", itemType)
 	var u string
+	parentId := launchId
 	if parentItemId != "" {
+		parentId = parentItemId
 		u = fmt.Sprintf("%s/%s/item/%s", c.ApiURL, c.Project, parentItemId)
 	} else {
 		u = fmt.Sprintf("%s/%s/item", c.ApiURL, c.Project)
 	}
-	req, err := c.newRequest("POST", u, p, "application/json")
+	req, err := c.newRequest(ctx, "POST", u, p, "application/json")
 	if err != nil {
 		return StartTestItemResponse{}, err
 	}
 	var respBody StartTestItemResponse
-	_, err = c.do(req, &respBody)
+	var spooled bool
+	itemId := placeholderId(itemUuid)
+	if c.async != nil {
+		err = c.async.start(itemUuid, parentId, req)
+	} else {
+		spooled, err = c.report(req, &respBody, itemId)
+	}
 	if err != nil {
 		return StartTestItemResponse{}, err
 	}
-	c.Stack.Push(respBody.Id)
+	if itemUuid != "" || spooled {
+		respBody.Id = itemId
+	}
 	c.l.Debugf("started test item: %s", respBody.Id)
 	return respBody, err
 }
 
-func (c *Client) FinishTestItem(status string, endTimeStringRFC3339 string, issue map[string]interface{}) (string, error) {
+func (c *Client) finishItem(ctx context.Context, launchId string, id string, status string, endTimeStringRFC3339 string, issue map[string]interface{}) (string, error) {
 	if issue == nil && status == "SKIPPED" {
-		issue = make(map[string]interface{})
-		issue["issue_type"] = "NOT_ISSUE"
-	}
-	var endTime string
-	if endTimeStringRFC3339 != "" {
-		endTime = endTimeStringRFC3339
-	} else {
-		endTime = time.Now().Format(time.RFC3339)
-	}
-	p := FinishTestItemPayload{
-		EndTime: endTime,
-		Status:  status,
-		Issue:   issue,
-	}
-	itemId := c.Stack.Pop()
-	c.l.Debugf("finishing test item", "itemid", itemId, "status", status, "issue", issue)
-	req, err := c.newRequest("PUT", fmt.Sprintf("%s/%s/item/%s", c.ApiURL, c.Project, itemId), p, "application/json")
-	if err != nil {
-		return "", err
+		// legacy v1 servers expect NOT_ISSUE for skipped items
+		issue = map[string]interface{}{"issue_type": "NOT_ISSUE"}
+		if c.APIVersion == 2 {
+			issue = c.issueMap(ItemIssue{IssueType: DefectNoDefect})
+		}
 	}
-	var respBody FinishTestItemResponse
-	_, err = c.do(req, &respBody)
-	if err != nil {
-		return "", err
+	if c.scanner != nil {
+		defer c.scanner.forget(id)
 	}
-	c.l.Debugf("finished test item: %s", respBody.Msg)
-	return respBody.Msg, err
-}
-
-func (c *Client) FinishTestItemId(id string, status string, endTimeStringRFC3339 string, issue map[string]interface{}) (string, error) {
-	if issue == nil && status == "SKIPPED" {
-		issue = make(map[string]interface{})
-		issue["issue_type"] = "NOT_ISSUE"
+	var flushErr error
+	if c.logBuf != nil {
+		if err := c.logBuf.flushItem(ctx, id); err != nil {
+			flushErr = &logsNotSentError{err: err}
+		}
 	}
 	var endTime string
 	if endTimeStringRFC3339 != "" {
@@ -290,136 +552,60 @@ func (c *Client) FinishTestItemId(id string, status string, endTimeStringRFC3339
 	} else {
 		endTime = time.Now().Format(time.RFC3339)
 	}
-	p := FinishTestItemPayload{
-		EndTime: endTime,
-		Status:  status,
-		Issue:   issue,
-	}
-	c.l.Debugf("finishing test item with id: %d, status: %s, issue: %s", id, status, issue)
-	req, err := c.newRequest("PUT", fmt.Sprintf("%s/%s/item/%s", c.ApiURL, c.Project, id), p, "application/json")
-	if err != nil {
-		return "", err
-	}
-	var respBody FinishTestItemResponse
-	_, err = c.do(req, &respBody)
+	p, err := c.finishTestItemPayload(launchId, status, endTime, issue)
 	if err != nil {
-		return "", err
+		return "", multierr.Append(flushErr, err)
 	}
-	c.l.Debugf("finished test item: %s", respBody.Msg)
-	return respBody.Msg, err
-}
-
-func (c *Client) LinkIssue(itemId int, ticketId string, url string) (string, error) {
-	project := strings.ToLower(strings.Split(ticketId, "-")[0])
-	p := LinkIssue{
-		Issues: []Issue{
-			{
-				BtsProject: project,
-				BtsUrl:     c.BTSUrl,
-				// the way we handle tickets breaks workflow of Report Portal,
-				// bugs should be created after run, not before
-				// we link tickets that already have been parsed out of logs
-				// so submit date cannot be obtained without jira client here
-				SubmitDate: time.Now().Unix() * 1000,
-				TicketId:   ticketId,
-				Url:        url,
-			},
-		},
-		TestItemIds: []int{
-			itemId,
-		},
-	}
-	c.l.Debugf("linking item issues with id: %d, ticketId: %s, link: %s", itemId, ticketId, url)
-	req, err := c.newRequest("PUT", fmt.Sprintf("%s/%s/item/issue/link", c.ApiURL, c.Project), p, "application/json")
+	c.l.Debugf("finishing test item with id: %s, status: %s, issue: %s", id, status, issue)
+	req, err := c.newRequest(ctx, "PUT", fmt.Sprintf("%s/%s/item/%s", c.ApiURL, c.Project, id), p, "application/json")
 	if err != nil {
-		return "", err
+		return "", multierr.Append(flushErr, err)
 	}
 	var respBody FinishTestItemResponse
-	_, err = c.do(req, &respBody)
-	if err != nil {
-		return "", err
-	}
-	c.l.Debugf("linked item issues: %s", respBody.Msg)
-	return respBody.Msg, err
-}
-
-func (c *Client) LogBatch(messages []LogPayload) error {
-	body := bytes.NewBufferString("")
-	bodyWriter := multipart.NewWriter(body)
-	mh := make(textproto.MIMEHeader)
-	mh.Set("Content-Type", "application/json")
-	mh.Set("Content-Disposition", `form-data; name="json_request_part"`)
-	pWriter, err := bodyWriter.CreatePart(mh)
-	if nil != err {
-		return err
-	}
-	buf := new(bytes.Buffer)
-	err = json.NewEncoder(buf).Encode(messages)
-	if err != nil {
-		return err
-	}
-	if _, err := io.Copy(pWriter, buf); err != nil {
-		return err
-	}
-	if err := bodyWriter.Close(); err != nil {
-		return err
+	if c.async != nil {
+		err = c.async.finish(id, req)
+	} else {
+		_, err = c.report(req, &respBody, "")
 	}
-	req, err := c.newRequest("POST", fmt.Sprintf("%s/%s/log", c.ApiURL, c.Project), body, bodyWriter.FormDataContentType())
 	if err != nil {
-		return err
+		return "", multierr.Append(flushErr, err)
 	}
-	var respBody LogResponse
-	_, err = c.do(req, &respBody)
-	if err != nil {
-		return err
+	if c.scanner != nil {
+		c.scanner.finished(ctx, id, status)
 	}
-	c.l.Debugf("log batch attached id: %s", respBody.Id)
-	return nil
+	c.l.Debugf("finished test item: %s", respBody.message())
+	return respBody.message(), flushErr
 }
 
-func (c *Client) Log(message string, level string) (string, error) {
-	var lastItemId string
-	if c.Stack.Peek() != nil {
-		lastItemId = c.Stack.Peek().(string)
-	} else {
-		return "", logNotAttachableToLaunchErr
+func (c *Client) logItem(ctx context.Context, launchId string, id string, message string, level string) (string, error) {
+	if c.scanner != nil {
+		c.scanner.scan(id, message)
 	}
 	p := LogPayload{
-		ItemId:  lastItemId,
+		ItemId:  id,
 		Time:    time.Now().Format(time.RFC3339),
 		Message: message,
 		Level:   level,
 	}
+	if c.logBuf != nil {
+		c.l.Debugf("buffering log of test item: %s, msg: %s, lvl: %s", p.ItemId, p.Message, p.Level)
+		return "", c.logBuf.add(ctx, launchId, p)
+	}
 	c.l.Debugf("attaching log to test item: %s, msg: %s, lvl: %s", p.ItemId, p.Message, p.Level)
-
-	req, err := c.newRequest("POST", fmt.Sprintf("%s/%s/log", c.ApiURL, c.Project), p, "application/json")
+	payload, err := c.logPayload(launchId, p)
 	if err != nil {
 		return "", err
 	}
-	var respBody LogResponse
-	_, err = c.do(req, &respBody)
-	if err != nil {
-		return "", err
-	}
-	c.l.Debugf("log attached id: %s", respBody.Id)
-	return respBody.Id, err
-}
-
-func (c *Client) LogId(id string, message string, level string) (string, error) {
-	p := LogPayload{
-		ItemId:  id,
-		Time:    time.Now().Format(time.RFC3339),
-		Message: message,
-		Level:   level,
-	}
-	c.l.Debugf("attaching log to test item: %s, msg: %s, lvl: %s", p.ItemId, p.Message, p.Level)
-
-	req, err := c.newRequest("POST", fmt.Sprintf("%s/%s/log", c.ApiURL, c.Project), p, "application/json")
+	req, err := c.newRequest(ctx, "POST", fmt.Sprintf("%s/%s/log", c.ApiURL, c.Project), payload, "application/json")
 	if err != nil {
 		return "", err
 	}
 	var respBody LogResponse
-	_, err = c.do(req, &respBody)
+	if c.async != nil {
+		_, err = c.async.log([]string{p.ItemId}, req)
+	} else {
+		_, err = c.report(req, &respBody, "")
+	}
 	if err != nil {
 		return "", err
 	}
@@ -427,34 +613,21 @@ func (c *Client) LogId(id string, message string, level string) (string, error)
 	return respBody.Id, err
 }
 
-func (c *Client) GetItemIdByUUID(uuid string) (GetItemResponse, error) {
-	req, err := c.newRequest("GET", fmt.Sprintf("%s/%s/item/%s", c.ApiURL, c.Project, uuid), nil, "")
-	if err != nil {
-		return GetItemResponse{}, err
-	}
-	var respBody GetItemResponse
-	_, err = c.do(req, &respBody)
-	if err != nil {
-		return GetItemResponse{}, err
-	}
-	c.l.Debugf("get item id by uuid: %s\n", respBody)
-	return respBody, err
-}
-
-func (c *Client) newRequest(method, path string, body interface{}, contentType string) (*http.Request, error) {
+func (c *Client) newRequest(ctx context.Context, method, path string, body interface{}, contentType string) (*http.Request, error) {
 	rel := &url.URL{Path: path}
 	u := c.BaseURL.ResolveReference(rel)
-	var buf io.ReadWriter
-	if body != nil && contentType == "application/json" {
-		buf = new(bytes.Buffer)
-		err := json.NewEncoder(buf).Encode(body)
+	var buf io.Reader
+	if r, ok := body.(io.Reader); ok {
+		buf = r
+	} else if body != nil {
+		b := new(bytes.Buffer)
+		err := json.NewEncoder(b).Encode(body)
 		if err != nil {
 			return nil, err
 		}
-	} else if body != nil {
-		buf = body.(io.ReadWriter)
+		buf = b
 	}
-	req, err := http.NewRequest(method, u.String(), buf)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), buf)
 	if err != nil {
 		return nil, err
 	}
@@ -466,28 +639,64 @@ func (c *Client) newRequest(method, path string, body interface{}, contentType s
 }
 
 func (c *Client) do(req *http.Request, v interface{}) (*http.Response, error) {
-	var err error
-	var resp *http.Response
-	for i := 0; i <= c.Retries; i++ {
-		resp, err = c.httpClient.Do(req)
+	ctx := req.Context()
+	idempotent := c.retryPolicy.IdempotentMethods[req.Method] || c.clientIdStart(req)
+	var lastErr error
+	for i := 0; ; i++ {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if i > 0 && req.Body != nil {
+			// body is drained by previous attempt
+			if req.GetBody == nil {
+				return nil, &bodyNotRewindableError{last: lastErr}
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+		var wait time.Duration
+		resp, err := c.httpClient.Do(req)
 		if err != nil {
 			c.l.Error(err)
-			continue
-		}
-		if resp != nil && resp.StatusCode >= 400 {
-			c.l.Errorf("request failed: status: %s", resp.Status)
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
+			if !c.retryPolicy.retryError(idempotent, err) {
+				return nil, err
+			}
+			lastErr = err
+		} else if resp.StatusCode >= 400 {
+			bb, _ := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			c.l.Errorf("request failed: status: %s, body: %s", resp.Status, string(bb))
+			apiErr := newAPIError(req, resp, bb)
+			if !c.retryPolicy.retryStatus(idempotent, resp.StatusCode) {
+				return resp, apiErr
+			}
+			lastErr = apiErr
+			if d, ok := retryAfter(resp); ok {
+				wait = c.retryPolicy.clamp(d)
+			}
+		} else {
 			if resp.Body != nil {
-				bb, _ := ioutil.ReadAll(resp.Body)
-				defer resp.Body.Close()
-				c.l.Errorf("body: %s, err: %s", resp.Status, string(bb), err)
+				err = json.NewDecoder(resp.Body).Decode(v)
+				err = resp.Body.Close()
 			}
-			continue
+			return resp, err
+		}
+		if i >= c.Retries {
+			break
 		}
-		if resp != nil && resp.Body != nil {
-			err = json.NewDecoder(resp.Body).Decode(v)
-			err = resp.Body.Close()
+		if wait == 0 {
+			wait = c.retryPolicy.backoff(i)
+		}
+		c.l.Debugf("retrying %s %s in %s", req.Method, req.URL.Path, wait)
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
 		}
-		return resp, err
 	}
-	return nil, httpRetriesReachedErr
+	return nil, &retriesReachedError{last: lastErr}
 }
@@ -1,32 +1,36 @@
 package rpgoclient
 
 import (
-	"log"
 	"testing"
 	"time"
+
+	"github.com/skudasov/rpgoclient/rptest"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestStartLaunch(t *testing.T) {
-	c := New("https://rp.insolar.io/", "skudasov_personal", "f6f757d0-f9b5-4188-b950-41a8b89492e1", "jj", true)
-	_, err := c.StartLaunch("test", "test_desc", time.Now().Format(time.RFC3339), []string{"tag1", "tag2"}, "DEFAULT")
-	if err != nil {
-		log.Fatal(err)
+	s := rptest.NewServer()
+	defer s.Close()
+	c := New(s.URL, "skudasov_personal", "f6f757d0-f9b5-4188-b950-41a8b89492e1", "jj", true)
+	resp, err := c.StartLaunch("test", "test_desc", time.Now().Format(time.RFC3339), []string{"tag1", "tag2"}, "DEFAULT")
+	assert.NoError(t, err)
+	l, ok := s.Launch(resp.Id)
+	if assert.True(t, ok) {
+		assert.Equal(t, "test_desc", l.Description)
+		assert.Equal(t, []string{"tag1", "tag2"}, l.Tags)
 	}
 }
 
-//func TestClientGetItem(t *testing.T) {
-//c := New("https://rp.dev.insolar.io/", "skudasov_personal", "f6f757d0-f9b5-4188-b950-41a8b89492e1", "jj", "btsUrl", true)
-//_, err := c.GetItemIdByUUID("5f304ca4-0be6-41af-8078-03b6a29a0a2d")
-//if err != nil {
-//	log.Fatal(err)
-//}
-//_, err := c.GetItemIdByUniqId("368", "auto:58fc6db8f10ae27c6c49bf4e6ecb2b1c")
-//if err != nil {
-//	log.Fatal(err)
-//}
-//res, err := c.StartLaunch("abc", "abc", time.Now().Format(time.RFC3339), []string{}, "DEFAULT")
-//if err != nil {
-//	log.Fatal(err)
-//}
-//fmt.Printf("res: %v\n", res)
-//}
+func TestClientGetItem(t *testing.T) {
+	s := rptest.NewServer()
+	defer s.Close()
+	c := New(s.URL, "skudasov_personal", "f6f757d0-f9b5-4188-b950-41a8b89492e1", "jj", false)
+	_, err := c.StartLaunch("abc", "abc", time.Now().Format(time.RFC3339), []string{}, "DEFAULT")
+	assert.NoError(t, err)
+	item, err := c.StartTestItem("item", "TEST", "", "", nil, nil)
+	assert.NoError(t, err)
+	res, err := c.GetItemIdByUUID(item.Id)
+	assert.NoError(t, err)
+	it, _ := s.Item(item.Id)
+	assert.Equal(t, it.Id, res.Id)
+}
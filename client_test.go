@@ -1,23 +1,40 @@
 package rpgoclient
 
 import (
+	"archive/zip"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
 	"log"
+	"math/rand"
 	"mime"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
+
+	"github.com/skudasov/rpgoclient/rptest"
 )
 
 var C *Client
 
 const (
 	token      = ""
-	project    = ""
+	project    = "testproj"
 	btsProject = ""
 	btsUrl     = ""
 	ua         = "testuseragent"
@@ -49,7 +66,7 @@ func TestClient_StartLaunch(t *testing.T) {
 		t.Error(err)
 	}
 	assert.NotNil(t, launchId)
-	assert.Equal(t, 1, C.Stack.Len())
+	assert.Equal(t, 1, C.StackLen())
 }
 
 func TestClient_StartLaunchWithTime(t *testing.T) {
@@ -78,7 +95,7 @@ func TestClient_StartLaunchWithTime(t *testing.T) {
 		t.Error(err)
 	}
 	assert.NotNil(t, launchId)
-	assert.Equal(t, 1, C.Stack.Len())
+	assert.Equal(t, 1, C.StackLen())
 }
 
 func TestClient_FinishLaunch(t *testing.T) {
@@ -100,14 +117,14 @@ func TestClient_FinishLaunch(t *testing.T) {
 	defer ts.Close()
 
 	C = New(ts.URL, project, token, btsProject, false)
-	C.Stack.Push(nil)
-	C.LaunchId = "launch_id"
+	C.launch = C.LaunchHandle("launch_id")
+	C.stack = []*Item{nil}
 	msg, err := C.FinishLaunch("PASSED", "")
 	if err != nil {
 		t.Fatal(err)
 	}
-	assert.Equal(t, fmt.Sprintf("Launch with ID = '%s' successfully finished.", "launch_id"), msg)
-	assert.Equal(t, 0, C.Stack.Len())
+	assert.Equal(t, "1", msg.Id)
+	assert.Equal(t, 0, C.StackLen())
 }
 
 func TestClient_StartTestItem(t *testing.T) {
@@ -132,7 +149,7 @@ func TestClient_StartTestItem(t *testing.T) {
 	}))
 	defer ts.Close()
 	C = New(ts.URL, project, token, btsProject, false)
-	C.LaunchId = "launch_id"
+	C.launch = C.LaunchHandle("launch_id")
 	params := make([]map[string]string, 0)
 	params = append(params, map[string]string{"key": "sdf", "value": "sdF"})
 	launchId, err := C.StartTestItem("test_item", "SUITE", "", "test suite description", []string{"tag1"}, params)
@@ -140,7 +157,7 @@ func TestClient_StartTestItem(t *testing.T) {
 		t.Error(err)
 	}
 	assert.NotNil(t, launchId)
-	assert.Equal(t, 1, C.Stack.Len())
+	assert.Equal(t, 1, C.StackLen())
 }
 
 func TestClient_StartTestItemWithTimeRFC3339(t *testing.T) {
@@ -165,7 +182,7 @@ func TestClient_StartTestItemWithTimeRFC3339(t *testing.T) {
 	}))
 	defer ts.Close()
 	C = New(ts.URL, project, token, btsProject, false)
-	C.LaunchId = "launch_id"
+	C.launch = C.LaunchHandle("launch_id")
 	params := make([]map[string]string, 0)
 	params = append(params, map[string]string{"key": "sdf", "value": "sdF"})
 	launchId, err := C.StartTestItem("test_item", "SUITE", "2019-02-01T14:21:30.049304+03:00", "test suite description", []string{"tag1"}, params)
@@ -173,7 +190,7 @@ func TestClient_StartTestItemWithTimeRFC3339(t *testing.T) {
 		t.Error(err)
 	}
 	assert.NotNil(t, launchId)
-	assert.Equal(t, 1, C.Stack.Len())
+	assert.Equal(t, 1, C.StackLen())
 }
 
 func TestClient_FinishTestItem(t *testing.T) {
@@ -195,13 +212,14 @@ func TestClient_FinishTestItem(t *testing.T) {
 	defer ts.Close()
 
 	C = New(ts.URL, project, token, btsProject, false)
-	C.Stack.Push("parent_item_id")
+	C.launch = C.LaunchHandle("launch_id")
+	C.stack = []*Item{{Id: "parent_item_id", launch: C.launch}}
 	msg, err := C.FinishTestItem("PASSED", "", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
 	assert.Equal(t, fmt.Sprintf("Launch with ID = '%s' successfully finished.", "launch_id"), msg)
-	assert.Equal(t, 0, C.Stack.Len())
+	assert.Equal(t, 0, C.StackLen())
 }
 
 func TestClient_FinishTestItemWithEndTime(t *testing.T) {
@@ -224,13 +242,14 @@ func TestClient_FinishTestItemWithEndTime(t *testing.T) {
 	defer ts.Close()
 
 	C = New(ts.URL, project, token, btsProject, false)
-	C.Stack.Push("parent_item_id")
+	C.launch = C.LaunchHandle("launch_id")
+	C.stack = []*Item{{Id: "parent_item_id", launch: C.launch}}
 	msg, err := C.FinishTestItem("PASSED", "2019-02-01T14:21:30.049304+03:00", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
 	assert.Equal(t, fmt.Sprintf("Launch with ID = '%s' successfully finished.", "launch_id"), msg)
-	assert.Equal(t, 0, C.Stack.Len())
+	assert.Equal(t, 0, C.StackLen())
 }
 
 func TestClient_Log(t *testing.T) {
@@ -254,14 +273,14 @@ func TestClient_Log(t *testing.T) {
 	defer ts.Close()
 
 	C = New(ts.URL, project, token, btsProject, false)
-	C.Stack.Push(nil)
-	C.Stack.Push("last_item_id")
+	C.launch = C.LaunchHandle("launch_id")
+	C.stack = []*Item{nil, {Id: "last_item_id", launch: C.launch}}
 	msg, err := C.Log("logmessage", "DEBUG")
 	if err != nil {
 		t.Fatal(err)
 	}
 	assert.Equal(t, "log_id", msg)
-	assert.Equal(t, 2, C.Stack.Len())
+	assert.Equal(t, 2, C.StackLen())
 }
 
 func TestClient_LogBatch(t *testing.T) {
@@ -288,8 +307,8 @@ func TestClient_LogBatch(t *testing.T) {
 	defer ts.Close()
 
 	C = New(ts.URL, project, token, btsProject, false)
-	C.Stack.Push(nil)
-	C.Stack.Push("last_item_id")
+	C.launch = C.LaunchHandle("launch_id")
+	C.stack = []*Item{nil, {Id: "last_item_id", launch: C.launch}}
 	err := C.LogBatch([]LogPayload{
 		{ItemId: "", Time: time.Now().String(), Message: "abc", Level: "INFO"},
 		{ItemId: "", Time: time.Now().String(), Message: "def", Level: "INFO"},
@@ -297,7 +316,7 @@ func TestClient_LogBatch(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	assert.Equal(t, 2, C.Stack.Len())
+	assert.Equal(t, 2, C.StackLen())
 }
 
 func TestClient_LogNotAttachableToLaunchItem(t *testing.T) {
@@ -321,12 +340,50 @@ func TestClient_LogNotAttachableToLaunchItem(t *testing.T) {
 	defer ts.Close()
 
 	C = New(ts.URL, project, token, btsProject, false)
-	C.Stack.Push(nil) // launch item
+	C.launch = C.LaunchHandle("launch_id")
+	C.stack = []*Item{nil} // launch item
 	_, err := C.Log("logmessage", "DEBUG")
 	if err != nil {
 		assert.Equal(t, "cannot attach log to launch item, only to test items", err.Error())
 	}
-	assert.Equal(t, 1, C.Stack.Len())
+	assert.Equal(t, 1, C.StackLen())
+}
+
+func TestClient_StackItemsAreLaunchItems(t *testing.T) {
+	s := rptest.NewServer()
+	defer s.Close()
+	C = New(s.URL, project, token, btsProject, false)
+	_, err := C.StartTestItem("orphan", "TEST", "", "", nil, nil)
+	assert.True(t, errors.Is(err, ErrNoLaunchId))
+	_, err = C.StartLaunch("testrun", "", "", nil, "DEFAULT")
+	assert.NoError(t, err)
+	_, err = C.FinishTestItem("PASSED", "", nil)
+	assert.True(t, errors.Is(err, ErrNoTestItem))
+	suite, err := C.StartTestItem("suite", "SUITE", "", "", nil, nil)
+	assert.NoError(t, err)
+	test, err := C.StartTestItem("test", "TEST", "", "", nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, C.StackLen())
+
+	launch := C.CurrentLaunch()
+	assert.Equal(t, C.GetLaunchId(), launch.Id)
+	if items := launch.OpenItems(); assert.Len(t, items, 2) {
+		for _, it := range items {
+			if it.Id == test.Id {
+				assert.Equal(t, suite.Id, it.Parent().Id)
+			}
+		}
+	}
+	_, err = C.FinishTestItem("PASSED", "", nil)
+	assert.NoError(t, err)
+	_, err = C.FinishTestItem("PASSED", "", nil)
+	assert.NoError(t, err)
+	assert.Empty(t, launch.OpenItems())
+	_, err = C.FinishLaunch("PASSED", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, C.StackLen())
+	it, _ := s.Item(test.Id)
+	assert.Equal(t, suite.Id, it.ParentUuid)
 }
 
 func TestClient_FailedRequest(t *testing.T) {
@@ -353,7 +410,7 @@ func TestClient_FailedRequest(t *testing.T) {
 	launchId, err := C.StartLaunch("testrun", "", "", []string{"tag1"}, "DEFAULT")
 	assert.Error(t, err)
 	assert.Empty(t, launchId)
-	assert.Equal(t, 0, C.Stack.Len())
+	assert.Equal(t, 0, C.StackLen())
 }
 
 func TestOptions(t *testing.T) {
@@ -380,5 +437,1459 @@ func TestOptions(t *testing.T) {
 	launchId, err := C.StartLaunch("testrun", "", "", []string{"tag1"}, "DEFAULT")
 	assert.Error(t, err)
 	assert.Empty(t, launchId)
-	assert.Equal(t, 0, C.Stack.Len())
+	assert.Equal(t, 0, C.StackLen())
+}
+
+func TestClient_V2Reporting(t *testing.T) {
+	var launchUuid, itemUuid string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.String() {
+		case "/api/v2/testproj/launch":
+			var startLaunch *StartLaunchPayloadV2
+			err := json.NewDecoder(r.Body).Decode(&startLaunch)
+			if err != nil {
+				t.Error(err)
+			}
+			assert.Equal(t, "testrun", startLaunch.Name)
+			assert.Equal(t, int64(1549020090049), startLaunch.StartTime)
+			assert.Equal(t, []Attribute{{Value: "tag1"}, {Key: "branch", Value: "master"}}, startLaunch.Attributes)
+			assert.NotEmpty(t, startLaunch.Uuid)
+			launchUuid = startLaunch.Uuid
+			data, _ := json.Marshal(&StartLaunchResponse{Number: 1, Id: startLaunch.Uuid})
+			_, _ = w.Write(data)
+		case "/api/v2/testproj/item":
+			var startTestItem *StartTestItemPayloadV2
+			err := json.NewDecoder(r.Body).Decode(&startTestItem)
+			if err != nil {
+				t.Error(err)
+			}
+			assert.Equal(t, launchUuid, startTestItem.LaunchUuid)
+			assert.Equal(t, []Parameter{{Key: "sdf", Value: "sdF"}}, startTestItem.Parameters)
+			assert.NotEmpty(t, startTestItem.Uuid)
+			itemUuid = startTestItem.Uuid
+			data, _ := json.Marshal(&StartTestItemResponse{Id: startTestItem.Uuid})
+			_, _ = w.Write(data)
+		case "/api/v2/testproj/log":
+			var logPayload *LogPayloadV2
+			err := json.NewDecoder(r.Body).Decode(&logPayload)
+			if err != nil {
+				t.Error(err)
+			}
+			assert.Equal(t, itemUuid, logPayload.ItemUuid)
+			assert.Equal(t, launchUuid, logPayload.LaunchUuid)
+			assert.NotZero(t, logPayload.Time)
+			data, _ := json.Marshal(&LogResponse{Id: "log_uuid"})
+			_, _ = w.Write(data)
+		case "/api/v2/testproj/item/" + itemUuid:
+			var finishItem *FinishTestItemPayloadV2
+			err := json.NewDecoder(r.Body).Decode(&finishItem)
+			if err != nil {
+				t.Error(err)
+			}
+			assert.Equal(t, "PASSED", finishItem.Status)
+			assert.Equal(t, launchUuid, finishItem.LaunchUuid)
+			_, _ = w.Write([]byte(`{"message": "finished"}`))
+		default:
+			t.Errorf("unexpected request: %s", r.URL.String())
+		}
+	}))
+	defer ts.Close()
+	C = New(ts.URL, "testproj", token, btsProject, false, WithAPIVersion(2))
+	launch, err := C.StartLaunch("testrun", "test launch", "2019-02-01T14:21:30.049064+03:00", []string{"tag1", "branch:master"}, "DEFAULT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, launchUuid, launch.Id)
+	assert.Equal(t, launchUuid, C.GetLaunchId())
+	params := []map[string]string{{"key": "sdf", "value": "sdF"}}
+	item, err := C.StartTestItem("test_item", "SUITE", "", "test suite description", []string{"tag1"}, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, itemUuid, item.Id)
+	_, err = C.Log("logmessage", "DEBUG")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := C.FinishTestItem("PASSED", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "finished", msg)
+}
+
+func TestNewUUID(t *testing.T) {
+	id := newUUID()
+	assert.Regexp(t, "^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$", id)
+	assert.NotEqual(t, id, newUUID())
+}
+
+func TestClient_AsyncOrderedDelivery(t *testing.T) {
+	var mu sync.Mutex
+	started := make(map[string]bool)
+	finished := make(map[string]bool)
+	launchFinished := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Duration(rand.Intn(5)) * time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		assert.False(t, launchFinished, "request after launch finish: %s", r.URL.Path)
+		switch {
+		case r.URL.Path == "/api/v2/testproj/launch":
+			var p StartLaunchPayloadV2
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			started[p.Uuid] = true
+		case strings.HasSuffix(r.URL.Path, "/finish"):
+			launchFinished = true
+		case r.Method == "POST" && strings.HasPrefix(r.URL.Path, "/api/v2/testproj/item"):
+			var p StartTestItemPayloadV2
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			parent := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/api/v2/testproj/item"), "/")
+			if parent == "" {
+				parent = p.LaunchUuid
+			}
+			assert.True(t, started[parent], "child started before parent")
+			started[p.Uuid] = true
+		case r.Method == "PUT":
+			id := strings.TrimPrefix(r.URL.Path, "/api/v2/testproj/item/")
+			assert.True(t, started[id], "item finished before start")
+			finished[id] = true
+		case r.URL.Path == "/api/v2/testproj/log":
+			var p LogPayloadV2
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			assert.True(t, started[p.ItemUuid], "log sent before item start")
+			assert.False(t, finished[p.ItemUuid], "log sent after item finish")
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+	C = New(ts.URL, "testproj", token, btsProject, false, WithAPIVersion(2), WithAsync(4))
+	_, err := C.StartLaunch("testrun", "", "", nil, "DEFAULT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		_, err = C.StartTestItem(fmt.Sprintf("suite_%d", i), "SUITE", "", "", nil, nil)
+		assert.NoError(t, err)
+		for j := 0; j < 3; j++ {
+			_, err = C.StartTestItem(fmt.Sprintf("test_%d", j), "TEST", "", "", nil, nil)
+			assert.NoError(t, err)
+			_, err = C.Log("logmessage", "INFO")
+			assert.NoError(t, err)
+			_, err = C.FinishTestItem("PASSED", "", nil)
+			assert.NoError(t, err)
+		}
+		_, err = C.FinishTestItem("PASSED", "", nil)
+		assert.NoError(t, err)
+	}
+	_, err = C.FinishLaunch("PASSED", "")
+	assert.NoError(t, err)
+	assert.NoError(t, C.Close(context.Background()))
+	assert.True(t, launchFinished)
+	assert.Len(t, started, 41)
+	assert.Len(t, finished, 40)
+}
+
+func TestClient_AsyncFlushReturnsErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "wrong data", http.StatusBadRequest)
+	}))
+	defer ts.Close()
+	C = New(ts.URL, "testproj", token, btsProject, false, WithAPIVersion(2), WithAsync(2), WithRetries(0))
+	_, err := C.StartLaunch("testrun", "", "", nil, "DEFAULT")
+	assert.NoError(t, err)
+	_, err = C.StartTestItem("suite", "SUITE", "", "", nil, nil)
+	assert.NoError(t, err)
+	err = C.Flush(context.Background())
+	assert.Error(t, err)
+	assert.NoError(t, C.Flush(context.Background()))
+	assert.NoError(t, C.Close(context.Background()))
+	_, err = C.StartTestItem("suite", "SUITE", "", "", nil, nil)
+	assert.Equal(t, ErrAsyncReporterClosed, err)
+}
+
+func TestClient_AsyncAttachmentDelivered(t *testing.T) {
+	s := rptest.NewServer()
+	defer s.Close()
+	s.SetLatency(20 * time.Millisecond)
+	C = New(s.URL, project, token, btsProject, false, WithAPIVersion(2), WithAsync(2))
+	_, err := C.StartLaunch("testrun", "", "", nil, "DEFAULT")
+	assert.NoError(t, err)
+	item, err := C.StartTestItem("test", "TEST", "", "", nil, nil)
+	assert.NoError(t, err)
+	r := strings.NewReader("png data")
+	_, err = C.LogWithAttachment(item.Id, "screenshot", "ERROR", "page.png", "image/png", r)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, r.Len())
+	if logs := s.Logs(item.Id); assert.Len(t, logs, 1) && assert.NotNil(t, logs[0].File) {
+		assert.Equal(t, "png data", string(logs[0].File.Data))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = C.LogWithAttachmentContext(ctx, item.Id, "screenshot", "ERROR", "page.png", "image/png", strings.NewReader("png data"))
+	assert.Equal(t, context.Canceled, err)
+	_, err = C.FinishTestItem("PASSED", "", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, C.Close(context.Background()))
+	it, _ := s.Item(item.Id)
+	assert.True(t, it.Finished())
+}
+
+func TestClient_AsyncLogBatchDoesNotWait(t *testing.T) {
+	release := make(chan struct{})
+	var once sync.Once
+	unblock := func() { once.Do(func() { close(release) }) }
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && strings.HasPrefix(r.URL.Path, "/api/v2/testproj/item") {
+			<-release
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+	defer unblock()
+	C = New(ts.URL, "testproj", token, btsProject, false, WithAPIVersion(2), WithAsync(2))
+	_, err := C.StartLaunch("testrun", "", "", nil, "DEFAULT")
+	assert.NoError(t, err)
+	item, err := C.StartTestItem("test", "TEST", "", "", nil, nil)
+	assert.NoError(t, err)
+	logged := make(chan error, 1)
+	go func() {
+		logged <- C.LogBatch([]LogPayload{{ItemId: item.Id, Time: time.Now().Format(time.RFC3339), Message: "a", Level: "INFO"}})
+	}()
+	select {
+	case err := <-logged:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("log batch without attachments waits for delivery")
+	}
+	unblock()
+	assert.NoError(t, C.Close(context.Background()))
+}
+
+func TestClient_SpoolAndReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rpspool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	C = New(down.URL, "testproj", token, btsProject, false, WithRetries(0), WithSpool(dir))
+	_, err = C.StartLaunch("testrun", "", "", nil, "DEFAULT")
+	assert.NoError(t, err)
+	item, err := C.StartTestItem("test_item", "TEST", "", "", nil, nil)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, item.Id)
+	_, err = C.Log("logmessage", "INFO")
+	assert.NoError(t, err)
+	_, err = C.FinishTestItem("PASSED", "", nil)
+	assert.NoError(t, err)
+	_, err = C.FinishLaunch("PASSED", "")
+	assert.NoError(t, err)
+
+	var paths []string
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/api/v1/testproj/launch":
+			_, _ = w.Write([]byte(`{"id": "launch_1"}`))
+		case "/api/v1/testproj/item":
+			var p StartTestItemPayload
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			assert.Equal(t, "launch_1", p.LaunchId)
+			_, _ = w.Write([]byte(`{"id": "item_1"}`))
+		case "/api/v1/testproj/log":
+			var p LogPayload
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			assert.Equal(t, "item_1", p.ItemId)
+			_, _ = w.Write([]byte(`{"id": "log_1"}`))
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer up.Close()
+	rc := New(up.URL, "testproj", token, btsProject, false)
+	assert.NoError(t, rc.Replay(context.Background(), dir))
+	assert.Equal(t, []string{
+		"POST /api/v1/testproj/launch",
+		"POST /api/v1/testproj/item",
+		"POST /api/v1/testproj/log",
+		"PUT /api/v1/testproj/item/item_1",
+		"PUT /api/v1/testproj/launch/launch_1/finish",
+	}, paths)
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	assert.Empty(t, files)
+}
+
+func TestClient_SpoolAttachments(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rpspool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s := rptest.NewServer()
+	defer s.Close()
+	s.Fail("", "/", http.StatusServiceUnavailable, -1)
+	C = New(s.URL, project, token, btsProject, false, WithAPIVersion(2), WithRetries(0), WithSpool(dir))
+	_, err = C.StartLaunch("testrun", "", "", nil, "DEFAULT")
+	assert.NoError(t, err)
+	item, err := C.StartTestItem("test_item", "TEST", "", "", nil, nil)
+	assert.NoError(t, err)
+	_, err = C.LogWithAttachment(item.Id, "screenshot", "ERROR", "page.png", "image/png", bytes.NewReader([]byte("png data")))
+	assert.NoError(t, err)
+	_, err = C.LogWithAttachment(item.Id, "stream", "ERROR", "stream.txt", "text/plain", ioutil.NopCloser(strings.NewReader("data")))
+	assert.True(t, errors.Is(err, ErrBodyNotRewindable))
+	_, err = C.FinishTestItem("FAILED", "", nil)
+	assert.NoError(t, err)
+	_, err = C.FinishLaunch("FAILED", "")
+	assert.NoError(t, err)
+	parts, _ := filepath.Glob(filepath.Join(dir, "*.part"))
+	assert.Len(t, parts, 1)
+
+	s.Reset()
+	rc := New(s.URL, project, token, btsProject, false, WithAPIVersion(2))
+	assert.NoError(t, rc.Replay(context.Background(), dir))
+	if launches := s.Launches(); assert.Len(t, launches, 1) {
+		items := s.Children(launches[0].Uuid)
+		if assert.Len(t, items, 1) {
+			logs := s.Logs(items[0].Uuid)
+			if assert.Len(t, logs, 1) && assert.NotNil(t, logs[0].File) {
+				assert.Equal(t, "screenshot", logs[0].Message)
+				assert.Equal(t, "page.png", logs[0].File.Name)
+				assert.Equal(t, "png data", string(logs[0].File.Data))
+			}
+		}
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	assert.Empty(t, files)
+}
+
+func TestClient_SpoolUndeliverable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rpspool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	done := make(chan struct{})
+	var status int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&status) == 0 {
+			<-done
+			return
+		}
+		http.Error(w, "failed", int(atomic.LoadInt32(&status)))
+	}))
+	defer ts.Close()
+	defer close(done)
+	C = New(ts.URL, "testproj", token, btsProject, false, WithRetries(0), WithSpool(dir))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = C.StartLaunchContext(ctx, "testrun", "", "", nil, "DEFAULT")
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.False(t, C.spool.isOffline())
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	assert.Empty(t, files)
+
+	atomic.StoreInt32(&status, http.StatusBadGateway)
+	launch, err := C.StartLaunch("testrun", "", "", nil, "DEFAULT")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, launch.Id)
+	assert.True(t, C.spool.isOffline())
+}
+
+func TestClient_ContextCancelsRequest(t *testing.T) {
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer ts.Close()
+	defer close(done)
+	C = New(ts.URL, "testproj", token, btsProject, false, WithRetries(10))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	_, err := C.StartLaunchContext(ctx, "testrun", "", "", nil, "DEFAULT")
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.True(t, time.Since(started) < time.Second)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = C.LogIdContext(cancelled, "item_id", "logmessage", "INFO")
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestLaunchHandle_ParallelItems(t *testing.T) {
+	var mu sync.Mutex
+	var seq int
+	names := make(map[string]string)
+	parents := make(map[string]string)
+	logs := make(map[string]string)
+	finished := make(map[string]bool)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.URL.Path == "/api/v1/testproj/launch":
+			_, _ = w.Write([]byte(`{"id": "launch_id"}`))
+		case r.Method == "POST" && strings.HasPrefix(r.URL.Path, "/api/v1/testproj/item"):
+			var p StartTestItemPayload
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			assert.Equal(t, "launch_id", p.LaunchId)
+			seq++
+			id := fmt.Sprintf("item_%d", seq)
+			names[id] = p.Name
+			parents[id] = strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/api/v1/testproj/item"), "/")
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"id": "%s"}`, id)))
+		case r.URL.Path == "/api/v1/testproj/log":
+			var p LogPayload
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			logs[p.ItemId] = p.Message
+			_, _ = w.Write([]byte(`{"id": "log_id"}`))
+		case r.Method == "PUT" && strings.HasPrefix(r.URL.Path, "/api/v1/testproj/item/"):
+			finished[strings.TrimPrefix(r.URL.Path, "/api/v1/testproj/item/")] = true
+			_, _ = w.Write([]byte(`{"msg": "finished"}`))
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer ts.Close()
+	C = New(ts.URL, "testproj", token, btsProject, false)
+	ctx := context.Background()
+	launch, err := C.StartLaunchHandle(ctx, "testrun", "", "", nil, "DEFAULT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, C.StackLen())
+	root, err := launch.StartItem(ctx, "root", "SUITE", "", "", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("test_%d", i)
+			it, err := root.StartChild(ctx, name, "TEST", "", "", nil, nil)
+			if !assert.NoError(t, err) {
+				return
+			}
+			_, err = it.Log(ctx, name, "INFO")
+			assert.NoError(t, err)
+			_, err = it.Finish(ctx, "PASSED", "", nil)
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+	assert.Len(t, launch.OpenItems(), 1)
+	_, err = root.Finish(ctx, "PASSED", "", nil)
+	assert.NoError(t, err)
+	assert.Empty(t, launch.OpenItems())
+	_, err = launch.Finish(ctx, "PASSED", "")
+	assert.NoError(t, err)
+
+	assert.Len(t, names, 11)
+	for id, name := range names {
+		if name == "root" {
+			continue
+		}
+		assert.Equal(t, root.Id, parents[id])
+		assert.Equal(t, name, logs[id])
+		assert.True(t, finished[id])
+	}
+}
+
+func TestClient_RetryPolicy(t *testing.T) {
+	var attempts int
+	status := http.StatusServiceUnavailable
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		http.Error(w, "failed", status)
+	}))
+	defer ts.Close()
+	policy := DefaultRetryPolicy()
+	policy.MaxRetries = 2
+	policy.MinBackoff = time.Millisecond
+	C = New(ts.URL, "testproj", token, btsProject, false, WithRetryPolicy(policy))
+
+	_, err := C.StartLaunch("testrun", "", "", nil, "DEFAULT")
+	assert.True(t, errors.Is(err, ErrHTTPRetriesReached))
+	assert.Equal(t, 3, attempts)
+
+	attempts = 0
+	status = http.StatusBadRequest
+	_, err = C.StartLaunch("testrun", "", "", nil, "DEFAULT")
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+
+	attempts = 0
+	status = http.StatusInternalServerError
+	_, err = C.StartLaunch("testrun", "", "", nil, "DEFAULT")
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts, "non idempotent request processed by server must not be retried")
+	_, err = C.FinishTestItemId("item_id", "PASSED", "", nil)
+	assert.True(t, errors.Is(err, ErrHTTPRetriesReached))
+	assert.Equal(t, 4, attempts)
+}
+
+func TestClient_RetryPost(t *testing.T) {
+	var attempts int32
+	var fail func(w http.ResponseWriter)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			fail(w)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id": "launch_id"}`))
+	}))
+	defer ts.Close()
+	reset := func(w http.ResponseWriter) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		_ = conn.(*net.TCPConn).SetLinger(0)
+		conn.Close()
+	}
+	policy := DefaultRetryPolicy()
+	policy.MinBackoff = time.Millisecond
+
+	fail = func(w http.ResponseWriter) { http.Error(w, "bad gateway", http.StatusBadGateway) }
+	C = New(ts.URL, "testproj", token, btsProject, false, WithRetryPolicy(policy))
+	_, err := C.StartLaunch("testrun", "", "", nil, "DEFAULT")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+
+	atomic.StoreInt32(&attempts, 0)
+	fail = reset
+	_, err = C.StartLaunch("testrun", "", "", nil, "DEFAULT")
+	assert.Error(t, err, "v1 start is not idempotent")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+
+	atomic.StoreInt32(&attempts, 0)
+	C = New(ts.URL, "testproj", token, btsProject, false, WithAPIVersion(2), WithRetryPolicy(policy))
+	_, err = C.StartLaunch("testrun", "", "", nil, "DEFAULT")
+	assert.NoError(t, err, "v2 start is deduplicated by uuid")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+	assert.Equal(t, 100*time.Millisecond, p.backoff(0))
+	assert.Equal(t, 400*time.Millisecond, p.backoff(2))
+	assert.Equal(t, time.Second, p.backoff(10))
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := p.backoff(0)
+		assert.True(t, d >= 50*time.Millisecond && d <= 150*time.Millisecond)
+	}
+
+	d, ok := retryAfter(&http.Response{Header: http.Header{"Retry-After": []string{"3"}}})
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, d)
+	_, ok = retryAfter(&http.Response{Header: http.Header{}})
+	assert.False(t, ok)
+}
+
+func TestClient_RetryAfterClampedToMaxBackoff(t *testing.T) {
+	var attempts int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.Header().Set("Retry-After", "60")
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id": "launch_id"}`))
+	}))
+	defer ts.Close()
+	policy := DefaultRetryPolicy()
+	policy.MaxBackoff = 10 * time.Millisecond
+	C = New(ts.URL, "testproj", token, btsProject, false, WithRetryPolicy(policy))
+	started := time.Now()
+	launch, err := C.StartLaunch("testrun", "", "", nil, "DEFAULT")
+	assert.NoError(t, err)
+	assert.Equal(t, "launch_id", launch.Id)
+	assert.Equal(t, 2, attempts)
+	assert.True(t, time.Since(started) < time.Second)
+}
+
+func TestClient_RetryResendsSameBody(t *testing.T) {
+	var bodies [][]byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, b)
+		if len(bodies)%2 == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id": "log_id"}`))
+	}))
+	defer ts.Close()
+	policy := DefaultRetryPolicy()
+	policy.MinBackoff = time.Millisecond
+	C = New(ts.URL, "testproj", token, btsProject, false, WithRetryPolicy(policy))
+	err := C.LogBatch([]LogPayload{
+		{ItemId: "item_id", Time: time.Now().Format(time.RFC3339), Message: "abc", Level: "INFO"},
+		{ItemId: "item_id", Time: time.Now().Format(time.RFC3339), Message: "def", Level: "INFO"},
+	})
+	assert.NoError(t, err)
+	_, err = C.FinishTestItemId("item_id", "PASSED", "", nil)
+	assert.NoError(t, err)
+	if assert.Len(t, bodies, 4) {
+		assert.NotEmpty(t, bodies[0])
+		assert.Equal(t, bodies[0], bodies[1])
+		assert.NotEmpty(t, bodies[2])
+		assert.Equal(t, bodies[2], bodies[3])
+	}
+}
+
+func TestClient_APIError(t *testing.T) {
+	status := http.StatusNotFound
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"errorCode": 4041, "message": "Launch 'launch_id' not found"}`))
+	}))
+	defer ts.Close()
+	policy := DefaultRetryPolicy()
+	policy.MinBackoff = time.Millisecond
+	C = New(ts.URL, "testproj", token, btsProject, false, WithRetryPolicy(policy))
+	C.launch = C.LaunchHandle("launch_id")
+	_, err := C.FinishLaunch("PASSED", "")
+	var apiErr *APIError
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+		assert.Equal(t, 4041, apiErr.ErrorCode)
+		assert.Equal(t, "Launch 'launch_id' not found", apiErr.Message)
+		assert.Equal(t, "PUT", apiErr.Method)
+		assert.Equal(t, "/api/v1/testproj/launch/launch_id/finish", apiErr.Path)
+	}
+	assert.True(t, errors.Is(err, ErrResponse))
+	assert.False(t, errors.Is(err, ErrHTTPRetriesReached))
+
+	status = http.StatusServiceUnavailable
+	_, err = C.FinishLaunch("PASSED", "")
+	assert.True(t, errors.Is(err, ErrHTTPRetriesReached))
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	}
+
+	C.launch = nil
+	_, err = C.FinishLaunch("PASSED", "")
+	assert.True(t, errors.Is(err, ErrNoLaunchId))
+}
+
+func TestClient_LogWithAttachment(t *testing.T) {
+	var attempts int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/testproj/log", r.URL.String())
+		attempts++
+		_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		mr := multipart.NewReader(r.Body, params["boundary"])
+		p, err := mr.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var logPayloadBatch []LogPayload
+		err = json.NewDecoder(p).Decode(&logPayloadBatch)
+		assert.NoError(t, err)
+		if assert.Len(t, logPayloadBatch, 1) {
+			assert.Equal(t, "item_id", logPayloadBatch[0].ItemId)
+			assert.Equal(t, &LogFile{Name: "screenshot.png"}, logPayloadBatch[0].File)
+		}
+		f, err := mr.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "file", f.FormName())
+		assert.Equal(t, "screenshot.png", f.FileName())
+		assert.Equal(t, "image/png", f.Header.Get("Content-Type"))
+		data, _ := ioutil.ReadAll(f)
+		assert.Equal(t, "png data", string(data))
+		if attempts == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"responses": [{"id": "log_id"}]}`))
+	}))
+	defer ts.Close()
+	policy := DefaultRetryPolicy()
+	policy.MinBackoff = time.Millisecond
+	C = New(ts.URL, "testproj", token, btsProject, false, WithRetryPolicy(policy))
+	id, err := C.LogWithAttachment("item_id", "logmessage", "ERROR", "screenshot.png", "image/png", strings.NewReader("png data"))
+	assert.NoError(t, err)
+	assert.Equal(t, "log_id", id)
+	assert.Equal(t, 2, attempts)
+
+	attempts = 0
+	_, err = C.LogWithAttachment("item_id", "logmessage", "ERROR", "screenshot.png", "image/png", ioutil.NopCloser(strings.NewReader("png data")))
+	assert.True(t, errors.Is(err, ErrBodyNotRewindable))
+	var apiErr *APIError
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	}
+	assert.Equal(t, 1, attempts)
+}
+
+func TestClient_LogBatchSplitByMaxBytes(t *testing.T) {
+	var batches [][]LogPayload
+	var files []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		mr := multipart.NewReader(r.Body, params["boundary"])
+		p, err := mr.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var batch []LogPayload
+		_ = json.NewDecoder(p).Decode(&batch)
+		batches = append(batches, batch)
+		for {
+			f, err := mr.NextPart()
+			if err != nil {
+				break
+			}
+			files = append(files, f.FileName())
+		}
+		_, _ = w.Write([]byte(`{"responses": []}`))
+	}))
+	defer ts.Close()
+	C = New(ts.URL, "testproj", token, btsProject, false, WithLogBatchMaxBytes(1000))
+	now := time.Now().Format(time.RFC3339)
+	err := C.LogBatchWithAttachments([]LogPayload{
+		{ItemId: "item_id", Time: now, Message: "a", Level: "INFO"},
+		{ItemId: "item_id", Time: now, Message: "b", Level: "INFO", File: &LogFile{Name: "big.bin"}},
+		{ItemId: "item_id", Time: now, Message: "c", Level: "INFO"},
+		{ItemId: "item_id", Time: now, Message: "d", Level: "INFO", File: &LogFile{Name: "small.txt"}},
+	}, []Attachment{
+		{Name: "big.bin", Reader: bytes.NewReader(make([]byte, 2000))},
+		{Name: "small.txt", Reader: strings.NewReader("small")},
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, batches, 3) {
+		assert.Len(t, batches[0], 1)
+		assert.Equal(t, "b", batches[1][0].Message)
+		assert.Len(t, batches[2], 2)
+	}
+	assert.Equal(t, []string{"big.bin", "small.txt"}, files)
+}
+
+func TestClient_LogBuffer(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	var batches [][]LogPayload
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+			_, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			p, err := multipart.NewReader(r.Body, params["boundary"]).NextPart()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var batch []LogPayload
+			_ = json.NewDecoder(p).Decode(&batch)
+			batches = append(batches, batch)
+			_, _ = w.Write([]byte(`{"responses": []}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id": "id", "msg": "ok"}`))
+	}))
+	defer ts.Close()
+	C = New(ts.URL, "testproj", token, btsProject, false, WithLogBuffer(3, 0, 0))
+	for _, m := range []string{"a", "b", "c", "d"} {
+		id, err := C.LogId("item_1", m, "INFO")
+		assert.NoError(t, err)
+		assert.Empty(t, id)
+	}
+	_, err := C.LogId("item_2", "e", "INFO")
+	assert.NoError(t, err)
+	_, err = C.FinishTestItemId("item_1", "PASSED", "", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, C.Close(context.Background()))
+
+	assert.Equal(t, []string{
+		"POST /api/v1/testproj/log",
+		"POST /api/v1/testproj/log",
+		"PUT /api/v1/testproj/item/item_1",
+		"POST /api/v1/testproj/log",
+	}, requests)
+	if assert.Len(t, batches, 3) {
+		assert.Len(t, batches[0], 3)
+		assert.Equal(t, []LogPayload{{ItemId: "item_1", Time: batches[1][0].Time, Message: "d", Level: "INFO"}}, batches[1])
+		assert.Equal(t, "e", batches[2][0].Message)
+	}
+}
+
+func TestClient_LogBufferInterval(t *testing.T) {
+	logs := make(chan struct{}, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logs <- struct{}{}
+		_, _ = w.Write([]byte(`{"responses": []}`))
+	}))
+	defer ts.Close()
+	C = New(ts.URL, "testproj", token, btsProject, false, WithLogBuffer(0, 0, 10*time.Millisecond))
+	defer C.Close(context.Background())
+	_, err := C.LogId("item_1", "a", "INFO")
+	assert.NoError(t, err)
+	select {
+	case <-logs:
+	case <-time.After(time.Second):
+		t.Fatal("buffered log was not flushed on interval")
+	}
+}
+
+func TestClient_LogBufferFlushFailure(t *testing.T) {
+	s := rptest.NewServer()
+	defer s.Close()
+	C = New(s.URL, project, token, btsProject, false, WithAPIVersion(2), WithLogBuffer(10, 0, 0))
+	launch, err := C.StartLaunch("nightly", "", "", nil, "DEFAULT")
+	assert.NoError(t, err)
+	item, err := C.StartTestItem("test", "TEST", "", "", nil, nil)
+	assert.NoError(t, err)
+	_, err = C.Log("lost", "INFO")
+	assert.NoError(t, err)
+	s.Fail("POST", "/log", http.StatusBadRequest, -1)
+	_, err = C.FinishTestItem("PASSED", "", nil)
+	assert.True(t, errors.Is(err, ErrLogsNotSent))
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	it, _ := s.Item(item.Id)
+	assert.True(t, it.Finished())
+
+	_, err = C.LogId(item.Id, "lost", "INFO")
+	assert.NoError(t, err)
+	_, err = C.FinishLaunch("PASSED", "")
+	assert.True(t, errors.Is(err, ErrLogsNotSent))
+	l, _ := s.Launch(launch.Id)
+	assert.True(t, l.Finished())
+	assert.Equal(t, 0, C.StackLen())
+}
+
+func TestClient_ListLaunches(t *testing.T) {
+	s := rptest.NewServer()
+	defer s.Close()
+	C = New(s.URL, project, token, btsProject, false, WithAPIVersion(2))
+	start := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	for i, branch := range []string{"master", "dev", "master"} {
+		_, err := C.StartLaunch("nightly", "", start.Add(time.Duration(i)*time.Hour).Format(time.RFC3339), []string{"branch:" + branch}, "DEFAULT")
+		assert.NoError(t, err)
+		_, err = C.StartTestItem("test", "TEST", "", "", nil, nil)
+		assert.NoError(t, err)
+		_, err = C.FinishTestItem("FAILED", "", nil)
+		assert.NoError(t, err)
+		_, err = C.FinishLaunch("FAILED", "")
+		assert.NoError(t, err)
+	}
+	_, err := C.StartLaunch("smoke", "", "", []string{"branch:master"}, "DEFAULT")
+	assert.NoError(t, err)
+
+	filter := NewFilter().
+		Eq("name", "nightly").
+		Has("compositeAttribute", "branch:master").
+		Gte("startTime", strconv.FormatInt(start.UnixNano()/int64(time.Millisecond), 10))
+	page, err := C.ListLaunches(context.Background(), filter, Page{Size: 1, Sort: []string{"startTime,DESC"}})
+	assert.NoError(t, err)
+	assert.Equal(t, PageInfo{Number: 1, Size: 1, TotalElements: 2, TotalPages: 2}, page.Page)
+	if assert.Len(t, page.Content, 1) {
+		l := page.Content[0]
+		assert.Equal(t, 3, l.Number)
+		assert.Equal(t, start.Add(2*time.Hour), l.StartTime.UTC())
+		assert.Equal(t, []Attribute{{Key: "branch", Value: "master"}}, l.Attributes)
+		assert.Equal(t, 1, l.Statistics.Executions["failed"])
+		assert.Equal(t, 1, l.Statistics.Defects["to_investigate"]["TI001"])
+
+		byUuid, err := C.GetLaunch(context.Background(), l.Uuid)
+		assert.NoError(t, err)
+		byId, err := C.GetLaunch(context.Background(), strconv.Itoa(l.Id))
+		assert.NoError(t, err)
+		assert.Equal(t, byUuid, byId)
+		assert.Equal(t, "FAILED", byId.Status)
+	}
+
+	all, err := C.ListLaunches(context.Background(), nil, Page{})
+	assert.NoError(t, err)
+	assert.Len(t, all.Content, 4)
+	assert.Equal(t, "IN_PROGRESS", all.Content[3].Status)
+
+	_, err = C.GetLaunch(context.Background(), "404")
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+}
+
+func TestClient_ListItems(t *testing.T) {
+	s := rptest.NewServer()
+	defer s.Close()
+	C = New(s.URL, project, token, btsProject, false, WithAPIVersion(2))
+	launch, err := C.StartLaunch("nightly", "", "", nil, "DEFAULT")
+	assert.NoError(t, err)
+	suite, err := C.StartTestItem("suite", "SUITE", "", "", nil, nil)
+	assert.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		_, err = C.StartTestItem(fmt.Sprintf("test%d", i), "TEST", "", "", []string{"k:v"}, nil)
+		assert.NoError(t, err)
+		status := "PASSED"
+		if i == 3 {
+			status = "FAILED"
+		}
+		_, err = C.FinishTestItem(status, "", nil)
+		assert.NoError(t, err)
+	}
+	_, err = C.FinishTestItem("FAILED", "", nil)
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	page, err := C.ListItems(ctx, launch.Id, NewFilter().Eq("status", "FAILED").Eq("type", "TEST"), Page{})
+	assert.NoError(t, err)
+	if assert.Len(t, page.Content, 1) {
+		it := page.Content[0]
+		assert.Equal(t, "test3", it.Name)
+		assert.Equal(t, "TI001", it.Issue.IssueType)
+		assert.Equal(t, []Attribute{{Key: "k", Value: "v"}}, it.Attributes)
+		assert.Equal(t, LaunchPathName{Name: "nightly", Number: 1}, it.PathNames.LaunchPathName)
+		assert.Equal(t, "suite", it.PathNames.ItemPaths[0].Name)
+		assert.Equal(t, fmt.Sprintf("%d.%d", it.Parent, it.Id), it.Path)
+
+		byId, err := C.GetItem(ctx, strconv.Itoa(it.Id))
+		assert.NoError(t, err)
+		assert.Equal(t, it, byId)
+		ids, err := C.GetItemIdByUniqId(strconv.Itoa(it.LaunchId), it.UniqueId)
+		assert.NoError(t, err)
+		assert.Equal(t, []ItemContent{{Id: it.Id}}, ids.Content)
+	}
+
+	parent, err := C.GetItem(ctx, suite.Id)
+	assert.NoError(t, err)
+	assert.True(t, parent.HasChildren)
+	assert.Equal(t, 5, parent.Statistics.Executions["total"])
+
+	var names []string
+	it := C.IterateItems(ctx, launch.Id, NewFilter().Eq("parentId", strconv.Itoa(parent.Id)), Page{Size: 2, Sort: []string{"name,DESC"}})
+	for it.Next() {
+		names = append(names, it.Item().Name)
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, []string{"test4", "test3", "test2", "test1", "test0"}, names)
+
+	s.Fail("GET", "/item", 400, 1)
+	it = C.IterateItems(ctx, "", nil, Page{})
+	assert.False(t, it.Next())
+	assert.Error(t, it.Err())
+}
+
+func TestClient_GetItemHistory(t *testing.T) {
+	s := rptest.NewServer()
+	defer s.Close()
+	C = New(s.URL, project, token, btsProject, false)
+	var last StartTestItemResponse
+	for _, status := range []string{"PASSED", "FAILED", "SKIPPED", "PASSED", "FAILED"} {
+		_, err := C.StartLaunch("nightly", "", "", nil, "DEFAULT")
+		assert.NoError(t, err)
+		_, err = C.StartTestItem("suite", "SUITE", "", "", nil, nil)
+		assert.NoError(t, err)
+		last, err = C.StartTestItem("flaky", "TEST", "", "", nil, nil)
+		assert.NoError(t, err)
+		_, err = C.FinishTestItem(status, "", nil)
+		assert.NoError(t, err)
+		_, err = C.FinishTestItem(status, "", nil)
+		assert.NoError(t, err)
+		_, err = C.FinishLaunch(status, "")
+		assert.NoError(t, err)
+	}
+	id, err := C.GetItemIdByUUID(last.Id)
+	assert.NoError(t, err)
+	history, err := C.GetItemHistory(context.Background(), []int{id.Id}, 4)
+	assert.NoError(t, err)
+	if assert.Len(t, history, 1) {
+		assert.Equal(t, []string{"FAILED", "PASSED", "SKIPPED", "FAILED"}, history[0].Statuses())
+		assert.Equal(t, 2, history[0].Flips())
+		assert.Equal(t, 5, history[0].Resources[0].PathNames.LaunchPathName.Number)
+	}
+	_, err = C.GetItemHistory(context.Background(), nil, 4)
+	assert.Error(t, err)
+}
+
+func TestClient_MergeShards(t *testing.T) {
+	s := rptest.NewServer()
+	defer s.Close()
+	var shards []*Client
+	for i := 0; i < 2; i++ {
+		c := New(s.URL, project, token, btsProject, false, WithAPIVersion(2))
+		_, err := c.StartLaunch(fmt.Sprintf("shard%d", i), "", "", []string{"pipeline:42", fmt.Sprintf("shard:%d", i)}, "DEFAULT")
+		assert.NoError(t, err)
+		_, err = c.StartTestItem("suite", "SUITE", "", "", nil, nil)
+		assert.NoError(t, err)
+		_, err = c.StartTestItem(fmt.Sprintf("test%d", i), "TEST", "", "", nil, nil)
+		assert.NoError(t, err)
+		_, err = c.FinishTestItem("PASSED", "", nil)
+		assert.NoError(t, err)
+		_, err = c.FinishTestItem("PASSED", "", nil)
+		assert.NoError(t, err)
+		shards = append(shards, c)
+	}
+	_, err := shards[0].FinishLaunch("PASSED", "")
+	assert.NoError(t, err)
+
+	type result struct {
+		l   LaunchResource
+		err error
+	}
+	done := make(chan result)
+	go func() {
+		l, err := shards[0].MergeShards(context.Background(), "pipeline:42", 2, "pipeline 42", MergeDeep, 10*time.Millisecond)
+		done <- result{l, err}
+	}()
+	time.Sleep(50 * time.Millisecond)
+	_, err = shards[1].FinishLaunch("FAILED", "")
+	assert.NoError(t, err)
+	res := <-done
+	assert.NoError(t, res.err)
+	assert.Equal(t, "pipeline 42", res.l.Name)
+	assert.Equal(t, "FAILED", res.l.Status)
+	assert.ElementsMatch(t, []Attribute{{Key: "shard", Value: "0"}, {Key: "shard", Value: "1"}}, res.l.Attributes)
+	assert.Equal(t, 2, res.l.Statistics.Executions["total"])
+	if assert.Len(t, s.Launches(), 1) {
+		suites := s.Children(res.l.Uuid)
+		if assert.Len(t, suites, 1) {
+			assert.Len(t, s.Children(suites[0].Uuid), 2)
+		}
+	}
+
+	_, err = shards[0].MergeShards(context.Background(), "pipeline:42", 0, "pipeline 42", MergeBasic, time.Millisecond)
+	assert.Error(t, err)
+	_, err = shards[0].MergeShards(context.Background(), "pipeline:42", 1, "pipeline 42", MergeBasic, 0)
+	assert.Error(t, err)
+	for i := 0; i < 2; i++ {
+		_, err = shards[i].StartLaunch("shard", "", "", []string{"pipeline:44"}, "DEFAULT")
+		assert.NoError(t, err)
+	}
+	_, err = shards[0].MergeShards(context.Background(), "pipeline:44", 1, "pipeline 44", MergeBasic, time.Millisecond)
+	assert.EqualError(t, err, "found 2 launches with attribute pipeline:44, expected 1 shards")
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = shards[0].MergeShards(ctx, "pipeline:43", 1, "pipeline 43", MergeBasic, time.Millisecond)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestClient_MergeLaunches(t *testing.T) {
+	s := rptest.NewServer()
+	defer s.Close()
+	C = New(s.URL, project, token, btsProject, false)
+	var ids []int
+	for i := 0; i < 2; i++ {
+		launch, err := C.StartLaunch("shard", "", "", nil, "DEFAULT")
+		assert.NoError(t, err)
+		_, err = C.StartTestItem("suite", "SUITE", "", "", nil, nil)
+		assert.NoError(t, err)
+		_, err = C.FinishTestItem("PASSED", "", nil)
+		assert.NoError(t, err)
+		_, err = C.FinishLaunch("PASSED", "")
+		assert.NoError(t, err)
+		l, err := C.GetLaunch(context.Background(), launch.Id)
+		assert.NoError(t, err)
+		ids = append(ids, l.Id)
+	}
+	merged, err := C.MergeLaunches(context.Background(), ids, "merged", MergeBasic, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "PASSED", merged.Status)
+	items, err := C.ListItems(context.Background(), merged.Uuid, nil, Page{})
+	assert.NoError(t, err)
+	if assert.Len(t, items.Content, 2) {
+		assert.Contains(t, items.Content[0].Description, "Merged from launch 'shard' #1")
+		assert.Contains(t, items.Content[1].Description, "Merged from launch 'shard' #2")
+	}
+
+	_, err = C.MergeLaunches(context.Background(), ids, "merged", MergeBasic, false)
+	var apiErr *APIError
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, 404, apiErr.StatusCode)
+	}
+}
+
+func TestClient_LaunchManagement(t *testing.T) {
+	s := rptest.NewServer()
+	defer s.Close()
+	C = New(s.URL, project, token, btsProject, false, WithAPIVersion(2))
+	crashed, err := C.StartLaunch("nightly", "", "", nil, "DEFAULT")
+	assert.NoError(t, err)
+	hung, err := C.StartTestItem("hung", "TEST", "", "", nil, nil)
+	assert.NoError(t, err)
+
+	_, err = C.StopLaunch(context.Background(), crashed.Id)
+	assert.NoError(t, err)
+	l, _ := s.Launch(crashed.Id)
+	assert.Equal(t, "STOPPED", l.Status)
+	it, _ := s.Item(hung.Id)
+	assert.Equal(t, "STOPPED", it.Status)
+	_, err = C.StopLaunch(context.Background(), crashed.Id)
+	assert.Error(t, err)
+
+	_, err = C.UpdateLaunch(context.Background(), crashed.Id, "runner crashed", []string{"nightly", "branch:dev"}, "DEBUG")
+	assert.NoError(t, err)
+	updated, err := C.GetLaunch(context.Background(), crashed.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, "runner crashed", updated.Description)
+	assert.Equal(t, "DEBUG", updated.Mode)
+	assert.Equal(t, []Attribute{{Value: "nightly"}, {Key: "branch", Value: "dev"}}, updated.Attributes)
+
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	w, err := zw.Create("report.xml")
+	assert.NoError(t, err)
+	_, err = io.WriteString(w, `<testsuites><testsuite name="suite"><testcase name="ok"/><testcase name="broken"><failure/></testcase></testsuite></testsuites>`)
+	assert.NoError(t, err)
+	assert.NoError(t, zw.Close())
+	s.Fail("POST", "/launch/import", 503, 1)
+	imported, err := C.ImportLaunch(context.Background(), "junit.zip", bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+	importedLaunch, err := C.GetLaunch(context.Background(), imported.LaunchId)
+	assert.NoError(t, err)
+	assert.Equal(t, "junit", importedLaunch.Name)
+	assert.Equal(t, "FAILED", importedLaunch.Status)
+	assert.Equal(t, 1, importedLaunch.Statistics.Executions["failed"])
+
+	inProgress, err := C.StartLaunch("running", "", "", nil, "DEFAULT")
+	assert.NoError(t, err)
+	running, err := C.GetLaunch(context.Background(), inProgress.Id)
+	assert.NoError(t, err)
+	deleted, err := C.DeleteLaunches(context.Background(), []int{updated.Id, importedLaunch.Id, running.Id, 404})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{updated.Id, importedLaunch.Id}, deleted.SuccessfullyDeleted)
+	assert.Equal(t, []int{404}, deleted.NotFound)
+	assert.Len(t, deleted.Errors, 1)
+	assert.Len(t, s.Launches(), 1)
+	assert.Empty(t, s.Children(importedLaunch.Uuid))
+}
+
+func TestClient_Guard(t *testing.T) {
+	s := rptest.NewServer()
+	defer s.Close()
+	C = New(s.URL, project, token, btsProject, false, WithAPIVersion(2), WithLogBuffer(10, 1<<20, 0))
+	var suite, test StartTestItemResponse
+	func() {
+		defer func() {
+			assert.Equal(t, "boom", recover())
+		}()
+		launch, err := C.StartLaunch("nightly", "", "", nil, "DEFAULT")
+		assert.NoError(t, err)
+		defer C.Guard()
+		suite, err = C.StartTestItem("suite", "SUITE", "", "", nil, nil)
+		assert.NoError(t, err)
+		test, err = C.StartTestItem("test", "TEST", "", "", nil, nil)
+		assert.NoError(t, err)
+		_, err = C.Log("before panic", "INFO")
+		assert.NoError(t, err)
+		defer func() {
+			l, _ := s.Launch(launch.Id)
+			assert.False(t, l.Finished())
+		}()
+		panic("boom")
+	}()
+	it, _ := s.Item(test.Id)
+	assert.Equal(t, "FAILED", it.Status)
+	if logs := s.Logs(test.Id); assert.Len(t, logs, 2) {
+		assert.Equal(t, "before panic", logs[0].Message)
+		assert.True(t, strings.HasPrefix(logs[1].Message, "panic: boom"))
+	}
+	it, _ = s.Item(suite.Id)
+	assert.Equal(t, "INTERRUPTED", it.Status)
+	assert.Equal(t, "INTERRUPTED", s.Launches()[0].Status)
+	assert.Equal(t, 0, C.StackLen())
+}
+
+func TestClient_FinishOnExit(t *testing.T) {
+	s := rptest.NewServer()
+	defer s.Close()
+	C = New(s.URL, project, token, btsProject, false, WithAPIVersion(2))
+	raised := make(chan os.Signal, 1)
+	defer func(raise func(os.Signal)) { raiseSignal = raise }(raiseSignal)
+	raiseSignal = func(sig os.Signal) { raised <- sig }
+
+	_, err := C.StartLaunch("nightly", "", "", nil, "DEFAULT")
+	assert.NoError(t, err)
+	stop := C.FinishOnExit()
+	defer stop()
+	test, err := C.StartTestItem("test", "TEST", "", "", nil, nil)
+	assert.NoError(t, err)
+	p, err := os.FindProcess(os.Getpid())
+	assert.NoError(t, err)
+	assert.NoError(t, p.Signal(syscall.SIGTERM))
+	select {
+	case sig := <-raised:
+		assert.Equal(t, syscall.SIGTERM, sig)
+	case <-time.After(5 * time.Second):
+		t.Fatal("signal is not re-raised")
+	}
+	it, _ := s.Item(test.Id)
+	assert.Equal(t, "INTERRUPTED", it.Status)
+	assert.Equal(t, "INTERRUPTED", s.Launches()[0].Status)
+}
+
+func TestClient_Defects(t *testing.T) {
+	s := rptest.NewServer()
+	defer s.Close()
+	C = New(s.URL, project, token, btsProject, false, WithAPIVersion(2))
+	flaky := s.AddDefectType("PRODUCT_BUG", "Flaky backend", "FB")
+	types, err := C.GetDefectTypes(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, types, 5)
+	if assert.Len(t, types["PRODUCT_BUG"], 2) {
+		assert.Equal(t, DefectProductBug, types["PRODUCT_BUG"][0].Locator)
+		assert.Equal(t, flaky, types["PRODUCT_BUG"][1].Locator)
+	}
+
+	run := func(issue *ItemIssue) (launchId string, login int, logout int) {
+		launch, err := C.StartLaunch("nightly", "", "", nil, "DEFAULT")
+		assert.NoError(t, err)
+		var ids []int
+		for _, name := range []string{"login", "logout"} {
+			item, err := C.StartTestItem(name, "TEST", "", "", nil, nil)
+			assert.NoError(t, err)
+			if issue != nil && name == "login" {
+				_, err = C.FinishTestItemIssue("FAILED", "", *issue)
+			} else {
+				_, err = C.FinishTestItem("FAILED", "", nil)
+			}
+			assert.NoError(t, err)
+			id, err := C.GetItemIdByUUID(item.Id)
+			assert.NoError(t, err)
+			ids = append(ids, id.Id)
+		}
+		_, err = C.FinishLaunch("FAILED", "")
+		assert.NoError(t, err)
+		return launch.Id, ids[0], ids[1]
+	}
+
+	_, login, logout := run(&ItemIssue{IssueType: flaky, Comment: "retries help"})
+	item, err := C.GetItem(context.Background(), strconv.Itoa(login))
+	assert.NoError(t, err)
+	if assert.NotNil(t, item.Issue) {
+		assert.Equal(t, flaky, item.Issue.IssueType)
+		assert.Equal(t, "retries help", item.Issue.Comment)
+	}
+	updated, err := C.UpdateDefects(context.Background(), []ItemDefect{{TestItemId: logout, Issue: ItemIssue{IssueType: DefectAutomationBug}}})
+	assert.NoError(t, err)
+	assert.Len(t, updated, 1)
+	_, err = C.UpdateDefects(context.Background(), []ItemDefect{{TestItemId: logout, Issue: ItemIssue{IssueType: "XX001"}}})
+	assert.Error(t, err)
+
+	launchId, login, logout := run(nil)
+	_, err = C.AnalyzeLaunch(context.Background(), launchId, AnalyzerModeLaunchName)
+	assert.NoError(t, err)
+	item, err = C.GetItem(context.Background(), strconv.Itoa(login))
+	assert.NoError(t, err)
+	if assert.NotNil(t, item.Issue) {
+		assert.Equal(t, flaky, item.Issue.IssueType)
+		assert.True(t, item.Issue.AutoAnalyzed)
+	}
+	item, err = C.GetItem(context.Background(), strconv.Itoa(logout))
+	assert.NoError(t, err)
+	if assert.NotNil(t, item.Issue) {
+		assert.Equal(t, DefectAutomationBug, item.Issue.IssueType)
+	}
+	launch, err := C.GetLaunch(context.Background(), launchId)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, launch.Statistics.Defects["product_bug"][flaky])
+	assert.Equal(t, 1, launch.Statistics.Defects["automation_bug"][DefectAutomationBug])
+}
+
+func TestClient_ParseTicket(t *testing.T) {
+	c := New("http://localhost", project, token, "https://jira.example.com", false, WithBTS(
+		JiraBTS{Url: "https://jira.example.com"},
+		GitHubBTS{Repo: "acme/api"},
+		GitLabBTS{Url: "https://gitlab.example.com/", Project: "group/web"},
+	))
+	for _, tc := range []struct {
+		ticketId string
+		want     Issue
+	}{
+		{"PROJ-123", Issue{BtsProject: "proj", BtsUrl: "https://jira.example.com", TicketId: "PROJ-123", Url: "https://jira.example.com/browse/PROJ-123"}},
+		{"acme/web#7", Issue{BtsProject: "acme/web", BtsUrl: "https://github.com", TicketId: "acme/web#7", Url: "https://github.com/acme/web/issues/7"}},
+		{"#8", Issue{BtsProject: "acme/api", BtsUrl: "https://github.com", TicketId: "acme/api#8", Url: "https://github.com/acme/api/issues/8"}},
+	} {
+		got, err := c.ParseTicket(tc.ticketId)
+		assert.NoError(t, err, tc.ticketId)
+		assert.Equal(t, tc.want, got, tc.ticketId)
+	}
+	_, err := c.ParseTicket("not a ticket")
+	assert.True(t, errors.Is(err, ErrUnknownTicket))
+
+	gitlab, ok := GitLabBTS{Url: "https://gitlab.example.com/", Project: "group/web"}.ParseTicket("#9")
+	assert.True(t, ok)
+	assert.Equal(t, "https://gitlab.example.com/group/web/-/issues/9", gitlab.Url)
+	redmine, ok := RedmineBTS{Url: "https://redmine.example.com", Project: "ops"}.ParseTicket("#42")
+	assert.True(t, ok)
+	assert.Equal(t, Issue{BtsProject: "ops", BtsUrl: "https://redmine.example.com", TicketId: "42", Url: "https://redmine.example.com/issues/42"}, redmine)
+	_, ok = GitHubBTS{}.ParseTicket("#1")
+	assert.False(t, ok)
+}
+
+func TestClient_IssueKeys(t *testing.T) {
+	for _, v := range []int{1, 2} {
+		s := rptest.NewServer()
+		C = New(s.URL, project, token, btsProject, false, WithAPIVersion(v))
+		_, err := C.StartLaunch("nightly", "", "", nil, "DEFAULT")
+		assert.NoError(t, err)
+		skipped, err := C.StartTestItem("skipped", "TEST", "", "", nil, nil)
+		assert.NoError(t, err)
+		_, err = C.FinishTestItem("SKIPPED", "", nil)
+		assert.NoError(t, err)
+		failed, err := C.StartTestItem("failed", "TEST", "", "", nil, nil)
+		assert.NoError(t, err)
+		_, err = C.FinishTestItemIssue("FAILED", "", ItemIssue{IssueType: DefectProductBug, Comment: "bug", IgnoreAnalyzer: true})
+		assert.NoError(t, err)
+		s.Close()
+
+		want := map[string]interface{}{"issueType": DefectNoDefect, "autoAnalyzed": false, "ignoreAnalyzer": false}
+		if v == 1 {
+			want = map[string]interface{}{"issue_type": "NOT_ISSUE"}
+		}
+		it, _ := s.Item(skipped.Id)
+		assert.Equal(t, want, it.Issue)
+		want = map[string]interface{}{"issueType": DefectProductBug, "autoAnalyzed": false, "ignoreAnalyzer": true, "comment": "bug"}
+		if v == 1 {
+			want = map[string]interface{}{"issue_type": DefectProductBug, "auto_analyzed": false, "ignore_analyzer": true, "comment": "bug"}
+		}
+		it, _ = s.Item(failed.Id)
+		assert.Equal(t, want, it.Issue)
+	}
+}
+
+func TestClient_LinkIssues(t *testing.T) {
+	s := rptest.NewServer()
+	defer s.Close()
+	C = New(s.URL, project, token, btsProject, false, WithBTS(GitHubBTS{}))
+	_, err := C.StartLaunch("nightly", "", "", nil, "DEFAULT")
+	assert.NoError(t, err)
+	var ids []int
+	for _, name := range []string{"a", "b"} {
+		item, err := C.StartTestItem(name, "TEST", "", "", nil, nil)
+		assert.NoError(t, err)
+		_, err = C.FinishTestItem("FAILED", "", nil)
+		assert.NoError(t, err)
+		id, err := C.GetItemIdByUUID(item.Id)
+		assert.NoError(t, err)
+		ids = append(ids, id.Id)
+	}
+	first, err := C.ParseTicket("acme/api#1")
+	assert.NoError(t, err)
+	submitted := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	first.SubmitDate = submitted.UnixNano() / int64(time.Millisecond)
+	second, err := C.ParseTicket("acme/api#2")
+	assert.NoError(t, err)
+	_, err = C.LinkIssues(context.Background(), ids, []Issue{first, second})
+	assert.NoError(t, err)
+	_, err = C.LinkIssue(ids[0], "acme/api#3", "")
+	assert.NoError(t, err)
+
+	a, _ := s.ItemByName("a")
+	if assert.Len(t, a.Tickets, 3) {
+		assert.Equal(t, "acme/api", a.Tickets[0].BtsProject)
+		assert.Equal(t, first.SubmitDate, a.Tickets[0].SubmitDate)
+		assert.NotZero(t, a.Tickets[1].SubmitDate)
+		assert.Equal(t, "https://github.com/acme/api/issues/3", a.Tickets[2].Url)
+	}
+
+	_, err = C.UnlinkIssues(context.Background(), ids, []string{"acme/api#1", "acme/api#3"})
+	assert.NoError(t, err)
+	for _, name := range []string{"a", "b"} {
+		it, _ := s.ItemByName(name)
+		if assert.Len(t, it.Tickets, 1) {
+			assert.Equal(t, "acme/api#2", it.Tickets[0].TicketId)
+		}
+	}
+
+	_, err = C.LinkIssue(ids[0], "PROJ-1", "")
+	assert.True(t, errors.Is(err, ErrUnknownTicket))
+	a, _ = s.ItemByName("a")
+	assert.Len(t, a.Tickets, 1)
+	legacy := New(s.URL, project, token, btsProject, false)
+	_, err = legacy.LinkIssue(ids[0], "PROJ-1", "")
+	assert.NoError(t, err)
+	a, _ = s.ItemByName("a")
+	if assert.Len(t, a.Tickets, 2) {
+		assert.Equal(t, "proj", a.Tickets[1].BtsProject)
+	}
+}
+
+func TestClient_TicketScanner(t *testing.T) {
+	s := rptest.NewServer()
+	defer s.Close()
+	scanner := WithTicketScanner(`KNOWN-BUG: ([A-Z]+-\d+)`, `acme/\w+#\d+`)
+	bts := WithBTS(JiraBTS{Url: "https://jira.example.com"}, GitHubBTS{})
+	C = New(s.URL, project, token, btsProject, false, WithAPIVersion(2), scanner, bts)
+	_, err := C.StartLaunch("nightly", "", "", nil, "DEFAULT")
+	assert.NoError(t, err)
+	failing, err := C.StartTestItem("failing", "TEST", "", "", nil, nil)
+	assert.NoError(t, err)
+	_, err = C.Log("assertion failed, KNOWN-BUG: PROJ-1", "ERROR")
+	assert.NoError(t, err)
+	err = C.LogBatch([]LogPayload{{ItemId: failing.Id, Time: time.Now().Format(time.RFC3339), Message: "see acme/api#5, KNOWN-BUG: PROJ-1", Level: "ERROR"}})
+	assert.NoError(t, err)
+	_, err = C.FinishTestItem("FAILED", "", nil)
+	assert.NoError(t, err)
+	_, err = C.StartTestItem("passing", "TEST", "", "", nil, nil)
+	assert.NoError(t, err)
+	_, err = C.Log("KNOWN-BUG: PROJ-2 is fixed", "INFO")
+	assert.NoError(t, err)
+	_, err = C.FinishTestItem("PASSED", "", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, C.Flush(context.Background()))
+
+	it, _ := s.ItemByName("failing")
+	if assert.Len(t, it.Tickets, 2) {
+		assert.Equal(t, "PROJ-1", it.Tickets[0].TicketId)
+		assert.Equal(t, "https://jira.example.com/browse/PROJ-1", it.Tickets[0].Url)
+		assert.Equal(t, "acme/api", it.Tickets[1].BtsProject)
+	}
+	it, _ = s.ItemByName("passing")
+	assert.Empty(t, it.Tickets)
+
+	async := New(s.URL, project, token, btsProject, false, WithAPIVersion(2), WithAsync(2), scanner, bts)
+	launch, err := async.StartLaunchHandle(context.Background(), "async", "", "", nil, "DEFAULT")
+	assert.NoError(t, err)
+	item, err := launch.StartItem(context.Background(), "async failing", "TEST", "", "", nil, nil)
+	assert.NoError(t, err)
+	_, err = item.Log(context.Background(), "KNOWN-BUG: PROJ-3", "ERROR")
+	assert.NoError(t, err)
+	_, err = item.Finish(context.Background(), "FAILED", "", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, async.Close(context.Background()))
+	it, _ = s.ItemByName("async failing")
+	if assert.Len(t, it.Tickets, 1) {
+		assert.Equal(t, "PROJ-3", it.Tickets[0].TicketId)
+	}
+
+	C = New(s.URL, project, token, btsProject, false, WithAPIVersion(2), WithTicketScanner(`TICKET: (\S+)`), WithBTS(GitHubBTS{}))
+	_, err = C.StartLaunch("unknown tickets", "", "", nil, "DEFAULT")
+	assert.NoError(t, err)
+	_, err = C.StartTestItem("unknown ticket", "TEST", "", "", nil, nil)
+	assert.NoError(t, err)
+	_, err = C.Log("TICKET: PROJ-9, TICKET: acme/api#7", "ERROR")
+	assert.NoError(t, err)
+	_, err = C.FinishTestItem("FAILED", "", nil)
+	assert.NoError(t, err)
+	assert.True(t, errors.Is(C.Flush(context.Background()), ErrUnknownTicket))
+	it, _ = s.ItemByName("unknown ticket")
+	if assert.Len(t, it.Tickets, 1) {
+		assert.Equal(t, "acme/api#7", it.Tickets[0].TicketId)
+	}
+
+	_, err = C.StartTestItem("rejected finish", "TEST", "", "", nil, nil)
+	assert.NoError(t, err)
+	_, err = C.Log("TICKET: acme/api#8", "ERROR")
+	assert.NoError(t, err)
+	s.Fail("PUT", "/item", http.StatusBadRequest, 1)
+	_, err = C.FinishTestItem("FAILED", "", nil)
+	assert.Error(t, err)
+	assert.Empty(t, C.scanner.tickets)
 }
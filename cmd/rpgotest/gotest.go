@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/skudasov/rpgoclient"
+	"go.uber.org/multierr"
+)
+
+// testEvent is a line of go test -json output, see go doc test2json
+type testEvent struct {
+	Time    time.Time
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+	Output  string
+}
+
+// goTestReporter reports go test -json events into the launch, packages become suites,
+// tests and subtests become nested items and output lines become logs
+type goTestReporter struct {
+	launch *rpgoclient.Launch
+	// packages are started on the first test event, so packages without tests are not reported
+	packages map[string]*goTestPackage
+	failed   bool
+}
+
+type goTestPackage struct {
+	item *rpgoclient.Item
+	// output is package output received before package item is started
+	output []string
+	tests  map[string]*rpgoclient.Item
+}
+
+func newGoTestReporter(launch *rpgoclient.Launch) *goTestReporter {
+	return &goTestReporter{launch: launch, packages: make(map[string]*goTestPackage)}
+}
+
+// report reads events until EOF, items left unfinished (panic, timeout) are finished as INTERRUPTED
+func (g *goTestReporter) report(ctx context.Context, r io.Reader) error {
+	var errs error
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16<<20)
+	for sc.Scan() {
+		line := sc.Bytes()
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		var ev testEvent
+		if err := json.Unmarshal(line, &ev); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("parse event %q: %w", line, err))
+			continue
+		}
+		errs = multierr.Append(errs, g.handle(ctx, ev))
+	}
+	errs = multierr.Append(errs, sc.Err())
+	return multierr.Append(errs, g.interrupt(ctx))
+}
+
+func (g *goTestReporter) handle(ctx context.Context, ev testEvent) error {
+	if ev.Package == "" {
+		return nil
+	}
+	pkg := g.packages[ev.Package]
+	if pkg == nil {
+		pkg = &goTestPackage{tests: make(map[string]*rpgoclient.Item)}
+		g.packages[ev.Package] = pkg
+	}
+	if ev.Test == "" {
+		return g.handlePackage(ctx, ev, pkg)
+	}
+	if pkg.item == nil {
+		if err := g.startPackage(ctx, ev, pkg); err != nil {
+			return err
+		}
+	}
+	switch ev.Action {
+	case "run":
+		parent := pkg.item
+		if i := strings.LastIndex(ev.Test, "/"); i > 0 {
+			if p, ok := pkg.tests[ev.Test[:i]]; ok {
+				parent = p
+			}
+		}
+		itemType := "TEST"
+		if parent != pkg.item {
+			itemType = "STEP"
+		}
+		it, err := parent.StartChild(ctx, ev.Test[strings.LastIndex(ev.Test, "/")+1:], itemType, formatTime(ev.Time), ev.Test, nil, nil)
+		if err != nil {
+			return err
+		}
+		pkg.tests[ev.Test] = it
+	case "output":
+		if it, ok := pkg.tests[ev.Test]; ok {
+			_, err := it.Log(ctx, strings.TrimRight(ev.Output, "\n"), "INFO")
+			return err
+		}
+	case "pass", "fail", "skip":
+		it, ok := pkg.tests[ev.Test]
+		if !ok {
+			return nil
+		}
+		delete(pkg.tests, ev.Test)
+		if ev.Action == "fail" {
+			g.failed = true
+		}
+		_, err := it.Finish(ctx, testStatus(ev.Action), formatTime(ev.Time), nil)
+		return err
+	}
+	return nil
+}
+
+func (g *goTestReporter) handlePackage(ctx context.Context, ev testEvent, pkg *goTestPackage) error {
+	switch ev.Action {
+	case "output":
+		if pkg.item == nil {
+			pkg.output = append(pkg.output, strings.TrimRight(ev.Output, "\n"))
+			return nil
+		}
+		_, err := pkg.item.Log(ctx, strings.TrimRight(ev.Output, "\n"), "INFO")
+		return err
+	case "pass", "fail", "skip":
+		// failed package without tests is a build or setup failure, it is reported with its output
+		if pkg.item == nil && ev.Action != "fail" {
+			delete(g.packages, ev.Package)
+			return nil
+		}
+		var errs error
+		if pkg.item == nil {
+			if err := g.startPackage(ctx, ev, pkg); err != nil {
+				return err
+			}
+		}
+		if ev.Action == "fail" {
+			g.failed = true
+		}
+		errs = multierr.Append(errs, g.finishTests(ctx, pkg, "INTERRUPTED", formatTime(ev.Time)))
+		_, err := pkg.item.Finish(ctx, testStatus(ev.Action), formatTime(ev.Time), nil)
+		delete(g.packages, ev.Package)
+		return multierr.Append(errs, err)
+	}
+	return nil
+}
+
+func (g *goTestReporter) startPackage(ctx context.Context, ev testEvent, pkg *goTestPackage) error {
+	it, err := g.launch.StartItem(ctx, ev.Package, "SUITE", formatTime(ev.Time), "", nil, nil)
+	if err != nil {
+		return err
+	}
+	pkg.item = it
+	var errs error
+	for _, out := range pkg.output {
+		_, err := it.Log(ctx, out, "INFO")
+		errs = multierr.Append(errs, err)
+	}
+	pkg.output = nil
+	return errs
+}
+
+// finishTests finishes tests of the package which are still running, subtests first
+func (g *goTestReporter) finishTests(ctx context.Context, pkg *goTestPackage, status string, endTime string) error {
+	names := make([]string, 0, len(pkg.tests))
+	for name := range pkg.tests {
+		names = append(names, name)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	var errs error
+	for _, name := range names {
+		_, err := pkg.tests[name].Finish(ctx, status, endTime, nil)
+		errs = multierr.Append(errs, err)
+		delete(pkg.tests, name)
+	}
+	return errs
+}
+
+// interrupt finishes packages which didn't report result
+func (g *goTestReporter) interrupt(ctx context.Context) error {
+	var errs error
+	now := formatTime(time.Now())
+	for name, pkg := range g.packages {
+		if pkg.item == nil {
+			continue
+		}
+		g.failed = true
+		errs = multierr.Append(errs, g.finishTests(ctx, pkg, "INTERRUPTED", now))
+		_, err := pkg.item.Finish(ctx, "INTERRUPTED", now, nil)
+		errs = multierr.Append(errs, err)
+		delete(g.packages, name)
+	}
+	return errs
+}
+
+func testStatus(action string) string {
+	switch action {
+	case "pass":
+		return "PASSED"
+	case "skip":
+		return "SKIPPED"
+	}
+	return "FAILED"
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
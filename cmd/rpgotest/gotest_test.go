@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/skudasov/rpgoclient"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeItem is an item recorded by fakeServer
+type fakeItem struct {
+	name   string
+	parent string
+	status string
+	logs   []string
+}
+
+// fakeServer records items reported through v2 api
+type fakeServer struct {
+	mu     sync.Mutex
+	items  map[string]*fakeItem
+	launch string
+}
+
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/"):
+		mr, _ := r.MultipartReader()
+		p, _ := mr.NextPart()
+		var logs []rpgoclient.LogPayloadV2
+		_ = json.NewDecoder(p).Decode(&logs)
+		for _, l := range logs {
+			s.items[l.ItemUuid].logs = append(s.items[l.ItemUuid].logs, l.Message)
+		}
+	case strings.HasSuffix(r.URL.Path, "/launch"):
+	case strings.HasSuffix(r.URL.Path, "/finish"):
+		var p rpgoclient.FinishLaunchPayloadV2
+		_ = json.NewDecoder(r.Body).Decode(&p)
+		s.launch = p.Status
+	case r.Method == "POST":
+		var p rpgoclient.StartTestItemPayloadV2
+		_ = json.NewDecoder(r.Body).Decode(&p)
+		parent := strings.TrimPrefix(r.URL.Path, "/api/v2/proj/item")
+		s.items[p.Uuid] = &fakeItem{name: p.Name, parent: strings.TrimPrefix(parent, "/")}
+	case r.Method == "PUT":
+		var p rpgoclient.FinishTestItemPayloadV2
+		_ = json.NewDecoder(r.Body).Decode(&p)
+		s.items[path.Base(r.URL.Path)].status = p.Status
+	}
+	_, _ = w.Write([]byte(`{"id": "id", "responses": []}`))
+}
+
+// tree maps item paths to their statuses
+func (s *fakeServer) tree() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := make(map[string]string)
+	for _, it := range s.items {
+		name := it.name
+		for p := s.items[it.parent]; p != nil; p = s.items[p.parent] {
+			name = p.name + "/" + name
+		}
+		res[name] = it.status
+	}
+	return res
+}
+
+func (s *fakeServer) logs(name string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, it := range s.items {
+		if it.name == name {
+			return it.logs
+		}
+	}
+	return nil
+}
+
+const goTestOutput = `{"Time":"2021-03-01T10:00:00Z","Action":"output","Package":"example.com/empty","Output":"?   \texample.com/empty\t[no test files]\n"}
+{"Time":"2021-03-01T10:00:00Z","Action":"skip","Package":"example.com/empty","Elapsed":0}
+{"Time":"2021-03-01T10:00:01Z","Action":"run","Package":"example.com/a","Test":"TestA"}
+{"Time":"2021-03-01T10:00:01Z","Action":"output","Package":"example.com/a","Test":"TestA","Output":"=== RUN   TestA\n"}
+{"Time":"2021-03-01T10:00:01Z","Action":"run","Package":"example.com/a","Test":"TestA/sub"}
+{"Time":"2021-03-01T10:00:01Z","Action":"output","Package":"example.com/a","Test":"TestA/sub","Output":"    a_test.go:10: broken\n"}
+{"Time":"2021-03-01T10:00:02Z","Action":"fail","Package":"example.com/a","Test":"TestA/sub","Elapsed":1}
+{"Time":"2021-03-01T10:00:02Z","Action":"fail","Package":"example.com/a","Test":"TestA","Elapsed":1}
+{"Time":"2021-03-01T10:00:02Z","Action":"run","Package":"example.com/a","Test":"TestB"}
+{"Time":"2021-03-01T10:00:02Z","Action":"skip","Package":"example.com/a","Test":"TestB","Elapsed":0}
+{"Time":"2021-03-01T10:00:03Z","Action":"fail","Package":"example.com/a","Elapsed":2}
+{"Time":"2021-03-01T10:00:03Z","Action":"output","Package":"example.com/broken","Output":"# example.com/broken\n"}
+{"Time":"2021-03-01T10:00:03Z","Action":"fail","Package":"example.com/broken","Elapsed":0}
+{"Time":"2021-03-01T10:00:04Z","Action":"run","Package":"example.com/b","Test":"TestC"}
+{"Time":"2021-03-01T10:00:04Z","Action":"run","Package":"example.com/b","Test":"TestC/hangs"}
+`
+
+func TestGoTestReporter(t *testing.T) {
+	s := &fakeServer{items: make(map[string]*fakeItem)}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	cfg := &config{endpoint: ts.URL, project: "proj", token: "token", launch: "go test", mode: "DEFAULT", apiVersion: 2, verbosity: "error"}
+	failed, err := runLaunch(context.Background(), cfg.newClient(), cfg, "", func(ctx context.Context, c *rpgoclient.Client) (bool, error) {
+		g := newGoTestReporter(c.LaunchHandle(c.GetLaunchId()))
+		err := g.report(ctx, strings.NewReader(goTestOutput))
+		return g.failed, err
+	})
+	assert.NoError(t, err)
+	assert.True(t, failed)
+	assert.Equal(t, "FAILED", s.launch)
+	assert.Equal(t, map[string]string{
+		"example.com/a":             "FAILED",
+		"example.com/a/TestA":       "FAILED",
+		"example.com/a/TestA/sub":   "FAILED",
+		"example.com/a/TestB":       "SKIPPED",
+		"example.com/broken":        "FAILED",
+		"example.com/b":             "INTERRUPTED",
+		"example.com/b/TestC":       "INTERRUPTED",
+		"example.com/b/TestC/hangs": "INTERRUPTED",
+	}, s.tree())
+	assert.Equal(t, []string{"    a_test.go:10: broken"}, s.logs("sub"))
+	assert.Equal(t, []string{"# example.com/broken"}, s.logs("example.com/broken"))
+}
@@ -0,0 +1,175 @@
+// Command rpgotest reports go test -json output or JUnit XML reports to Report Portal
+//
+//	go test -json ./... | rpgotest -endpoint http://localhost:8080 -project myproject -launch nightly
+//	rpgotest junit -endpoint http://localhost:8080 -project myproject -launch nightly reports/*.xml
+//
+// Packages are reported as suites, tests as nested items, subtests are split by "/",
+// output lines are attached as logs. JUnit suites and cases keep their original times.
+// Endpoint, project and token default to RP_ENDPOINT, RP_PROJECT and RP_TOKEN environment variables.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/skudasov/rpgoclient"
+	"github.com/skudasov/rpgoclient/junit"
+)
+
+// config is a set of flags common for all input formats
+type config struct {
+	endpoint    string
+	project     string
+	token       string
+	launch      string
+	description string
+	tags        string
+	mode        string
+	apiVersion  int
+	verbosity   string
+	exitCode    bool
+}
+
+func (cfg *config) register(fs *flag.FlagSet) {
+	fs.StringVar(&cfg.endpoint, "endpoint", os.Getenv("RP_ENDPOINT"), "Report Portal url")
+	fs.StringVar(&cfg.project, "project", os.Getenv("RP_PROJECT"), "Report Portal project")
+	fs.StringVar(&cfg.token, "token", os.Getenv("RP_TOKEN"), "Report Portal api token")
+	fs.StringVar(&cfg.launch, "launch", "go test", "launch name")
+	fs.StringVar(&cfg.description, "description", "", "launch description")
+	fs.StringVar(&cfg.tags, "tags", "", "comma separated launch tags, key:value tags are attributes in v2 api")
+	fs.StringVar(&cfg.mode, "mode", "DEFAULT", "launch mode, DEFAULT or DEBUG")
+	fs.IntVar(&cfg.apiVersion, "api", 1, "reporting api version, 1 or 2")
+	fs.StringVar(&cfg.verbosity, "verbosity", "info", "client log level")
+	fs.BoolVar(&cfg.exitCode, "set-exit-code", false, "exit with code 1 if tests failed")
+}
+
+func (cfg *config) validate() error {
+	if cfg.endpoint == "" || cfg.project == "" || cfg.token == "" {
+		return fmt.Errorf("endpoint, project and token are required")
+	}
+	return nil
+}
+
+func (cfg *config) splitTags() []string {
+	var tags []string
+	for _, t := range strings.Split(cfg.tags, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+func (cfg *config) newClient() *rpgoclient.Client {
+	return rpgoclient.New(cfg.endpoint, cfg.project, cfg.token, "", false,
+		rpgoclient.WithAPIVersion(cfg.apiVersion),
+		rpgoclient.WithVerbosity(cfg.verbosity),
+		rpgoclient.WithLogBuffer(100, 1<<20, time.Second),
+	)
+}
+
+// reportFunc reports results into current launch of the client and returns whether any test failed
+type reportFunc func(ctx context.Context, c *rpgoclient.Client) (failed bool, err error)
+
+// runLaunch starts launch at startTime, now if empty, reports results and finishes launch with PASSED or FAILED status
+func runLaunch(ctx context.Context, c *rpgoclient.Client, cfg *config, startTime string, report reportFunc) (bool, error) {
+	if _, err := c.StartLaunchContext(ctx, cfg.launch, cfg.description, startTime, cfg.splitTags(), cfg.mode); err != nil {
+		return false, err
+	}
+	failed, err := report(ctx, c)
+	status := "PASSED"
+	if failed {
+		status = "FAILED"
+	}
+	if _, ferr := c.FinishLaunchContext(ctx, status, ""); ferr != nil && err == nil {
+		err = ferr
+	}
+	if cerr := c.Close(ctx); cerr != nil && err == nil {
+		err = cerr
+	}
+	return failed, err
+}
+
+func openInput(args []string) (io.ReadCloser, error) {
+	if len(args) == 0 || args[0] == "-" {
+		return os.Stdin, nil
+	}
+	return os.Open(args[0])
+}
+
+// parseFlags parses common flags and exits with usage if required flags are missing
+func parseFlags(name string, usage string, args []string) (*config, []string) {
+	cfg := &config{}
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	cfg.register(fs)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	if err := cfg.validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fs.Usage()
+		os.Exit(2)
+	}
+	return cfg, fs.Args()
+}
+
+func runGoTest(args []string) (*config, bool, error) {
+	cfg, args := parseFlags("rpgotest", "usage: rpgotest [flags] [file]\n\nreads go test -json output from file or stdin\n\n", args)
+	in, err := openInput(args)
+	if err != nil {
+		return cfg, false, err
+	}
+	defer in.Close()
+	failed, err := runLaunch(context.Background(), cfg.newClient(), cfg, "", func(ctx context.Context, c *rpgoclient.Client) (bool, error) {
+		g := newGoTestReporter(c.CurrentLaunch())
+		err := g.report(ctx, in)
+		return g.failed, err
+	})
+	return cfg, failed, err
+}
+
+func runJUnit(args []string) (*config, bool, error) {
+	cfg, files := parseFlags("rpgotest junit", "usage: rpgotest junit [flags] file...\n\nreports JUnit XML files into one launch\n\n", args)
+	if len(files) == 0 {
+		return cfg, false, fmt.Errorf("no JUnit XML files given")
+	}
+	var suites []junit.Suite
+	for _, f := range files {
+		s, err := junit.ParseFile(f)
+		if err != nil {
+			return cfg, false, err
+		}
+		suites = append(suites, s...)
+	}
+	var startTime string
+	if t := junit.Earliest(suites); !t.IsZero() {
+		startTime = t.Format(time.RFC3339)
+	}
+	failed, err := runLaunch(context.Background(), cfg.newClient(), cfg, startTime, func(ctx context.Context, c *rpgoclient.Client) (bool, error) {
+		return junit.Report(ctx, c, suites)
+	})
+	return cfg, failed, err
+}
+
+func main() {
+	run := runGoTest
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "junit" {
+		run, args = runJUnit, args[1:]
+	}
+	cfg, failed, err := run(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if failed && cfg.exitCode {
+		os.Exit(1)
+	}
+}
@@ -1,10 +1,121 @@
 package rpgoclient
 
-import "errors"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
 
 var (
-	noLaunchIdErr               = errors.New("launch is not started, no LaunchId")
-	logNotAttachableToLaunchErr = errors.New("cannot attach log to launch item, only to test items")
-	responseErr                 = errors.New("failed to perform request")
-	httpRetriesReachedErr       = errors.New("http max retries reached")
+	ErrNoLaunchId               = errors.New("launch is not started, no LaunchId")
+	ErrLogNotAttachableToLaunch = errors.New("cannot attach log to launch item, only to test items")
+	ErrNoTestItem               = errors.New("no test item on the stack")
+	ErrResponse                 = errors.New("failed to perform request")
+	ErrHTTPRetriesReached       = errors.New("http max retries reached")
+	ErrUnsupportedAPIVersion    = errors.New("unsupported api version, only 1 and 2 are supported")
+	ErrAsyncRequiresV2          = errors.New("async reporting requires api version 2")
+	ErrAsyncReporterClosed      = errors.New("async reporter is closed")
+	ErrBodyNotRewindable        = errors.New("request body can not be rewound for retry")
+	ErrUnknownTicket            = errors.New("ticket does not match any bug tracking system")
+	ErrLogsNotSent              = errors.New("buffered logs are not sent")
 )
+
+// APIError is returned when Report Portal responds with error status,
+// it matches ErrResponse with errors.Is
+type APIError struct {
+	StatusCode int
+	// ErrorCode is Report Portal error code, e.g. 4041 for not found launch
+	ErrorCode int
+	Message   string
+	Method    string
+	Path      string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s %s: status: %d, error code: %d, message: %s", e.Method, e.Path, e.StatusCode, e.ErrorCode, e.Message)
+}
+
+func (e *APIError) Unwrap() error {
+	return ErrResponse
+}
+
+// newAPIError parses Report Portal error response body, raw body is used as message if it is not json
+func newAPIError(req *http.Request, resp *http.Response, body []byte) *APIError {
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		Method:     req.Method,
+		Path:       req.URL.Path,
+	}
+	var rpErr struct {
+		ErrorCode int    `json:"errorCode"`
+		Message   string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &rpErr); err == nil && (rpErr.ErrorCode != 0 || rpErr.Message != "") {
+		e.ErrorCode = rpErr.ErrorCode
+		e.Message = rpErr.Message
+	} else {
+		e.Message = string(body)
+	}
+	return e
+}
+
+// retriesReachedError is returned when all retries failed, it matches ErrHTTPRetriesReached with errors.Is
+// and unwraps to the error of the last attempt, e.g. *APIError
+type retriesReachedError struct {
+	last error
+}
+
+func (e *retriesReachedError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrHTTPRetriesReached, e.last)
+}
+
+func (e *retriesReachedError) Is(target error) bool {
+	return target == ErrHTTPRetriesReached
+}
+
+func (e *retriesReachedError) Unwrap() error {
+	return e.last
+}
+
+// bodyNotRewindableError is returned when failed request can't be retried because its body can't be rewound,
+// it matches ErrBodyNotRewindable with errors.Is and unwraps to the error of the last attempt, e.g. *APIError
+type bodyNotRewindableError struct {
+	last error
+}
+
+func (e *bodyNotRewindableError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrBodyNotRewindable, e.last)
+}
+
+func (e *bodyNotRewindableError) Is(target error) bool {
+	return target == ErrBodyNotRewindable
+}
+
+func (e *bodyNotRewindableError) Unwrap() error {
+	return e.last
+}
+
+// logsNotSentError is returned when item or launch is finished, but its buffered logs failed to send,
+// it matches ErrLogsNotSent with errors.Is and unwraps to the send error
+type logsNotSentError struct {
+	err error
+}
+
+func (e *logsNotSentError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrLogsNotSent, e.err)
+}
+
+func (e *logsNotSentError) Is(target error) bool {
+	return target == ErrLogsNotSent
+}
+
+func (e *logsNotSentError) Unwrap() error {
+	return e.err
+}
+
+// finishSent reports whether finish request was sent, err of finishItem and finishLaunch may be only about logs
+func finishSent(err error) bool {
+	_, ok := err.(*logsNotSentError)
+	return err == nil || ok
+}
@@ -1,12 +1,14 @@
 module github.com/skudasov/rpgoclient
 
+go 1.14
+
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/golang-collections/collections v0.0.0-20130729185459-604e922904d3
+	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/stretchr/objx v0.1.1 // indirect
 	github.com/stretchr/testify v1.3.0
 	github.com/uber-go/zap v1.9.1 // indirect
 	go.uber.org/atomic v1.3.2 // indirect
-	go.uber.org/multierr v1.1.0 // indirect
+	go.uber.org/multierr v1.1.0
 	go.uber.org/zap v1.9.1
 )
@@ -0,0 +1,106 @@
+package rpgoclient
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime/debug"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+// interruptTimeout bounds finishing of the launch on panic or signal, so unavailable server doesn't block exit
+const interruptTimeout = 30 * time.Second
+
+// raiseSignal re-raises signal after default handling is restored, it is replaced in tests
+var raiseSignal = func(sig os.Signal) {
+	p, err := os.FindProcess(os.Getpid())
+	if err == nil {
+		err = p.Signal(sig)
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// Guard finishes open items of the stack and the launch as INTERRUPTED when the calling function panics,
+// the item which panicked is finished as FAILED with panic logged, then the panic is re-raised.
+// It must be deferred directly
+//
+//	c.StartLaunch(...)
+//	defer c.Guard()
+func (c *Client) Guard() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), interruptTimeout)
+	defer cancel()
+	if err := c.interrupt(ctx, "FAILED", fmt.Sprintf("panic: %v\n\n%s", r, debug.Stack())); err != nil {
+		c.l.Errorf("failed to finish launch on panic: %s", err)
+	}
+	panic(r)
+}
+
+// FinishOnExit finishes open items of the stack and the launch as INTERRUPTED on SIGINT or SIGTERM,
+// flushes pending logs and re-raises the signal, returned stop func stops watching signals
+func (c *Client) FinishOnExit() (stop func()) {
+	sigs := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-sigs:
+			signal.Stop(sigs)
+			ctx, cancel := context.WithTimeout(context.Background(), interruptTimeout)
+			if err := c.interrupt(ctx, "INTERRUPTED", fmt.Sprintf("interrupted by signal: %s", sig)); err != nil {
+				c.l.Errorf("failed to finish launch on signal %s: %s", sig, err)
+			}
+			cancel()
+			raiseSignal(sig)
+		case <-done:
+			signal.Stop(sigs)
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// interrupt finishes items of the stack from the top, the top one with status and message logged,
+// others as INTERRUPTED, then finishes the launch as INTERRUPTED and flushes pending events
+func (c *Client) interrupt(ctx context.Context, status string, message string) error {
+	var errs error
+	top := true
+	for {
+		it := c.pop()
+		if it == nil {
+			break
+		}
+		itemStatus := "INTERRUPTED"
+		if top {
+			itemStatus = status
+			if _, err := it.Log(ctx, message, "ERROR"); err != nil {
+				errs = multierr.Append(errs, err)
+			}
+			top = false
+		}
+		if _, err := it.Finish(ctx, itemStatus, "", nil); err != nil {
+			errs = multierr.Append(errs, err)
+		}
+	}
+	c.mu.Lock()
+	started := c.launch != nil && len(c.stack) > 0
+	c.mu.Unlock()
+	if started {
+		if _, err := c.FinishLaunchContext(ctx, "INTERRUPTED", ""); err != nil {
+			errs = multierr.Append(errs, err)
+		}
+	}
+	return multierr.Append(errs, c.Flush(ctx))
+}
@@ -0,0 +1,69 @@
+package rpgoclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ItemHistory is history of one test across launches with the same name, Resources are runs of the test
+// ordered from the newest launch to the oldest
+type ItemHistory struct {
+	TestCaseHash int                `json:"testCaseHash"`
+	Resources    []TestItemResource `json:"resources"`
+}
+
+// Statuses returns statuses of the test from the newest launch to the oldest
+func (h ItemHistory) Statuses() []string {
+	res := make([]string, 0, len(h.Resources))
+	for _, r := range h.Resources {
+		res = append(res, r.Status)
+	}
+	return res
+}
+
+// Flips returns how many times status changed between consecutive launches, skipped runs are ignored,
+// often changing status is a sign of flaky test
+func (h ItemHistory) Flips() int {
+	var flips int
+	var prev string
+	for _, s := range h.Statuses() {
+		if s == "SKIPPED" {
+			continue
+		}
+		if prev != "" && s != prev {
+			flips++
+		}
+		prev = s
+	}
+	return flips
+}
+
+type itemHistoryPage struct {
+	Content []ItemHistory `json:"content"`
+}
+
+// GetItemHistory returns history of items by numeric ids over depth last launches
+func (c *Client) GetItemHistory(ctx context.Context, itemIds []int, depth int) ([]ItemHistory, error) {
+	if len(itemIds) == 0 {
+		return nil, errors.New("no item ids given")
+	}
+	ids := make([]string, 0, len(itemIds))
+	for _, id := range itemIds {
+		ids = append(ids, strconv.Itoa(id))
+	}
+	q := url.Values{}
+	q.Set("filter.in.id", strings.Join(ids, ","))
+	if depth > 0 {
+		q.Set("historyDepth", strconv.Itoa(depth))
+	}
+	var respBody itemHistoryPage
+	if err := c.get(ctx, fmt.Sprintf("%s/%s/item/history", c.apiV1URL(), c.Project), q, &respBody); err != nil {
+		return nil, err
+	}
+	c.l.Debugf("got history of %d items", len(respBody.Content))
+	return respBody.Content, nil
+}
@@ -0,0 +1,155 @@
+package rpgoclient
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// Locators of default defect types, custom subtypes get locators like PB_1h7inqu2gcbjd
+const (
+	DefectProductBug    = "PB001"
+	DefectAutomationBug = "AB001"
+	DefectSystemIssue   = "SI001"
+	DefectToInvestigate = "TI001"
+	DefectNoDefect      = "ND001"
+)
+
+// Analyzer modes select launches used as a base for analysis
+const (
+	AnalyzerModeAll           = "ALL"
+	AnalyzerModeLaunchName    = "LAUNCH_NAME"
+	AnalyzerModeCurrentLaunch = "CURRENT_LAUNCH"
+)
+
+// Analyze items modes select items of the launch to analyze
+const (
+	AnalyzeToInvestigate    = "TO_INVESTIGATE"
+	AnalyzeAutoAnalyzed     = "AUTO_ANALYZED"
+	AnalyzeManuallyAnalyzed = "MANUALLY_ANALYZED"
+)
+
+const defaultAnalyzerType = "autoAnalyzer"
+
+// DefectType is a defect type of project, TypeRef is its group, e.g. PRODUCT_BUG
+type DefectType struct {
+	Id        int    `json:"id"`
+	Locator   string `json:"locator"`
+	TypeRef   string `json:"typeRef"`
+	LongName  string `json:"longName"`
+	ShortName string `json:"shortName"`
+	Color     string `json:"color"`
+}
+
+type projectSettings struct {
+	SubTypes map[string][]DefectType `json:"subTypes"`
+}
+
+// ItemDefect is defect of item by numeric id for bulk update
+type ItemDefect struct {
+	TestItemId int       `json:"testItemId"`
+	Issue      ItemIssue `json:"issue"`
+}
+
+type defineIssuePayload struct {
+	Issues []ItemDefect `json:"issues"`
+}
+
+type analyzeLaunchPayload struct {
+	LaunchId         int      `json:"launchId"`
+	AnalyzerMode     string   `json:"analyzerMode"`
+	AnalyzerTypeName string   `json:"analyzerTypeName"`
+	AnalyzeItemsMode []string `json:"analyzeItemsMode"`
+}
+
+// issueMap converts issue to finish request issue of the api version, api v1 uses snake case keys
+// for type and analyzer flags
+func (c *Client) issueMap(issue ItemIssue) map[string]interface{} {
+	typeKey, autoAnalyzedKey, ignoreAnalyzerKey := "issueType", "autoAnalyzed", "ignoreAnalyzer"
+	if c.APIVersion != 2 {
+		typeKey, autoAnalyzedKey, ignoreAnalyzerKey = "issue_type", "auto_analyzed", "ignore_analyzer"
+	}
+	m := map[string]interface{}{
+		typeKey:           issue.IssueType,
+		autoAnalyzedKey:   issue.AutoAnalyzed,
+		ignoreAnalyzerKey: issue.IgnoreAnalyzer,
+	}
+	if issue.Comment != "" {
+		m["comment"] = issue.Comment
+	}
+	if len(issue.ExternalSystemIssues) > 0 {
+		m["externalSystemIssues"] = issue.ExternalSystemIssues
+	}
+	return m
+}
+
+func (c *Client) FinishTestItemIssue(status string, endTimeStringRFC3339 string, issue ItemIssue) (string, error) {
+	return c.FinishTestItemIssueContext(context.Background(), status, endTimeStringRFC3339, issue)
+}
+
+// FinishTestItemIssueContext finishes the item on top of the stack with defect
+func (c *Client) FinishTestItemIssueContext(ctx context.Context, status string, endTimeStringRFC3339 string, issue ItemIssue) (string, error) {
+	return c.FinishTestItemContext(ctx, status, endTimeStringRFC3339, c.issueMap(issue))
+}
+
+func (c *Client) FinishTestItemIdIssue(id string, status string, endTimeStringRFC3339 string, issue ItemIssue) (string, error) {
+	return c.FinishTestItemIdIssueContext(context.Background(), id, status, endTimeStringRFC3339, issue)
+}
+
+// FinishTestItemIdIssueContext finishes test item by id with defect
+func (c *Client) FinishTestItemIdIssueContext(ctx context.Context, id string, status string, endTimeStringRFC3339 string, issue ItemIssue) (string, error) {
+	return c.FinishTestItemIdContext(ctx, id, status, endTimeStringRFC3339, c.issueMap(issue))
+}
+
+// FinishIssue finishes the item with defect
+func (i *Item) FinishIssue(ctx context.Context, status string, endTimeStringRFC3339 string, issue ItemIssue) (string, error) {
+	return i.Finish(ctx, status, endTimeStringRFC3339, i.launch.c.issueMap(issue))
+}
+
+// GetDefectTypes returns defect types of the project grouped by type, e.g. PRODUCT_BUG,
+// including custom subtypes
+func (c *Client) GetDefectTypes(ctx context.Context) (map[string][]DefectType, error) {
+	var respBody projectSettings
+	if err := c.get(ctx, fmt.Sprintf("%s/%s/settings", c.apiV1URL(), c.Project), nil, &respBody); err != nil {
+		return nil, err
+	}
+	return respBody.SubTypes, nil
+}
+
+// UpdateDefects sets defects of finished items, it returns updated issues
+func (c *Client) UpdateDefects(ctx context.Context, defects []ItemDefect) ([]ItemIssue, error) {
+	var respBody []ItemIssue
+	if err := c.send(ctx, "PUT", fmt.Sprintf("%s/%s/item", c.apiV1URL(), c.Project), defineIssuePayload{Issues: defects}, &respBody); err != nil {
+		return nil, err
+	}
+	c.l.Debugf("defects updated: %d", len(respBody))
+	return respBody, nil
+}
+
+// AnalyzeLaunch starts auto-analyzer for finished launch by numeric id or uuid,
+// empty analyzerMode analyzes against the current launch, no itemsModes analyze items to investigate
+func (c *Client) AnalyzeLaunch(ctx context.Context, launchId string, analyzerMode string, itemsModes ...string) (OperationResponse, error) {
+	id, err := c.numericLaunchId(ctx, launchId)
+	if err != nil {
+		return OperationResponse{}, err
+	}
+	numId, _ := strconv.Atoi(id)
+	p := analyzeLaunchPayload{
+		LaunchId:         numId,
+		AnalyzerMode:     analyzerMode,
+		AnalyzerTypeName: defaultAnalyzerType,
+		AnalyzeItemsMode: itemsModes,
+	}
+	if p.AnalyzerMode == "" {
+		p.AnalyzerMode = AnalyzerModeCurrentLaunch
+	}
+	if len(p.AnalyzeItemsMode) == 0 {
+		p.AnalyzeItemsMode = []string{AnalyzeToInvestigate}
+	}
+	var respBody OperationResponse
+	if err := c.send(ctx, "POST", fmt.Sprintf("%s/%s/launch/analyze", c.apiV1URL(), c.Project), p, &respBody); err != nil {
+		return OperationResponse{}, err
+	}
+	c.l.Debugf("analyzer started for launch: %s", launchId)
+	return respBody, nil
+}
@@ -0,0 +1,185 @@
+package rpgoclient
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// ItemIssue is defect of test item, it is named so to not clash with bts ticket Issue
+type ItemIssue struct {
+	// IssueType is defect type locator, e.g. PB001
+	IssueType            string  `json:"issueType"`
+	Comment              string  `json:"comment,omitempty"`
+	AutoAnalyzed         bool    `json:"autoAnalyzed"`
+	IgnoreAnalyzer       bool    `json:"ignoreAnalyzer"`
+	ExternalSystemIssues []Issue `json:"externalSystemIssues,omitempty"`
+}
+
+type LaunchPathName struct {
+	Name   string `json:"name"`
+	Number int    `json:"number"`
+}
+
+type ItemPathName struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// PathNames are names of launch and ancestors of the item
+type PathNames struct {
+	LaunchPathName LaunchPathName `json:"launchPathName"`
+	ItemPaths      []ItemPathName `json:"itemPaths"`
+}
+
+// TestItemResource is a test item read from Report Portal, Path is ids of ancestors and the item joined by "."
+type TestItemResource struct {
+	Id          int         `json:"id"`
+	Uuid        string      `json:"uuid"`
+	Name        string      `json:"name"`
+	CodeRef     string      `json:"codeRef"`
+	Description string      `json:"description"`
+	Type        string      `json:"type"`
+	Status      string      `json:"status"`
+	StartTime   Timestamp   `json:"startTime"`
+	EndTime     Timestamp   `json:"endTime"`
+	LaunchId    int         `json:"launchId"`
+	Parent      int         `json:"parent"`
+	Path        string      `json:"path"`
+	PathNames   PathNames   `json:"pathNames"`
+	UniqueId    string      `json:"uniqueId"`
+	TestCaseId  string      `json:"testCaseId"`
+	HasChildren bool        `json:"hasChildren"`
+	HasStats    bool        `json:"hasStats"`
+	Attributes  []Attribute `json:"attributes"`
+	Parameters  []Parameter `json:"parameters"`
+	Issue       *ItemIssue  `json:"issue"`
+	Statistics  Statistics  `json:"statistics"`
+}
+
+type TestItemPage struct {
+	Content []TestItemResource `json:"content"`
+	Page    PageInfo           `json:"page"`
+}
+
+// ListItems returns page of items of the launch matching filter, launch is given by numeric id or uuid,
+// empty launchId lists items of all launches, filter may be nil
+func (c *Client) ListItems(ctx context.Context, launchId string, filter *Filter, page Page) (TestItemPage, error) {
+	q := query(filter, page)
+	if launchId != "" {
+		id, err := c.numericLaunchId(ctx, launchId)
+		if err != nil {
+			return TestItemPage{}, err
+		}
+		q.Set("filter.eq.launchId", id)
+	}
+	var respBody TestItemPage
+	if err := c.get(ctx, fmt.Sprintf("%s/%s/item", c.apiV1URL(), c.Project), q, &respBody); err != nil {
+		return TestItemPage{}, err
+	}
+	c.l.Debugf("listed items: %d of %d", len(respBody.Content), respBody.Page.TotalElements)
+	return respBody, nil
+}
+
+// GetItem returns item by numeric id or uuid
+func (c *Client) GetItem(ctx context.Context, id string) (TestItemResource, error) {
+	u := fmt.Sprintf("%s/%s/item/%s", c.apiV1URL(), c.Project, id)
+	if _, err := strconv.Atoi(id); err != nil {
+		u = fmt.Sprintf("%s/%s/item/uuid/%s", c.apiV1URL(), c.Project, id)
+	}
+	var respBody TestItemResource
+	if err := c.get(ctx, u, nil, &respBody); err != nil {
+		return TestItemResource{}, err
+	}
+	return respBody, nil
+}
+
+func (c *Client) GetItemIdByUniqId(launchId string, uniqueId string) (GetItemIdByUniqIdResponse, error) {
+	return c.GetItemIdByUniqIdContext(context.Background(), launchId, uniqueId)
+}
+
+// GetItemIdByUniqIdContext gets ids of launch items with unique id
+func (c *Client) GetItemIdByUniqIdContext(ctx context.Context, launchId string, uniqueId string) (GetItemIdByUniqIdResponse, error) {
+	var respBody GetItemIdByUniqIdResponse
+	err := c.get(ctx, fmt.Sprintf("%s/%s/item", c.apiV1URL(), c.Project), query(NewFilter().Eq("launchId", launchId).Eq("uniqueId", uniqueId), Page{}), &respBody)
+	if err != nil {
+		return GetItemIdByUniqIdResponse{}, err
+	}
+	c.l.Debugf("get item id by unique id: %v", respBody)
+	return respBody, nil
+}
+
+// numericLaunchId resolves launch uuid to numeric id used in filters
+func (c *Client) numericLaunchId(ctx context.Context, launchId string) (string, error) {
+	if _, err := strconv.Atoi(launchId); err == nil {
+		return launchId, nil
+	}
+	l, err := c.GetLaunch(ctx, launchId)
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(l.Id), nil
+}
+
+// ItemIterator walks items of all pages, pages are requested when previous page is read
+//
+//	it := c.IterateItems(ctx, launchId, nil, rpgoclient.Page{Size: 100})
+//	for it.Next() {
+//		item := it.Item()
+//	}
+//	if err := it.Err(); err != nil {...}
+type ItemIterator struct {
+	c        *Client
+	ctx      context.Context
+	launchId string
+	filter   *Filter
+	page     Page
+
+	items []TestItemResource
+	cur   TestItemResource
+	last  bool
+	err   error
+}
+
+// IterateItems returns iterator over items of the launch matching filter, starting from page.Number
+func (c *Client) IterateItems(ctx context.Context, launchId string, filter *Filter, page Page) *ItemIterator {
+	if page.Number < 1 {
+		page.Number = 1
+	}
+	return &ItemIterator{c: c, ctx: ctx, launchId: launchId, filter: filter, page: page}
+}
+
+// Next advances to the next item, it returns false when items are over or request failed
+func (it *ItemIterator) Next() bool {
+	for len(it.items) == 0 {
+		if it.last || it.err != nil {
+			return false
+		}
+		if it.launchId != "" {
+			// uuid is resolved once, not for every page
+			if it.launchId, it.err = it.c.numericLaunchId(it.ctx, it.launchId); it.err != nil {
+				return false
+			}
+		}
+		p, err := it.c.ListItems(it.ctx, it.launchId, it.filter, it.page)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.items = p.Content
+		it.last = len(p.Content) == 0 || p.Page.Number >= p.Page.TotalPages
+		it.page.Number++
+	}
+	it.cur, it.items = it.items[0], it.items[1:]
+	return true
+}
+
+// Item returns current item
+func (it *ItemIterator) Item() TestItemResource {
+	return it.cur
+}
+
+// Err returns error of the failed page request
+func (it *ItemIterator) Err() error {
+	return it.err
+}
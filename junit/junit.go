@@ -0,0 +1,261 @@
+// Package junit parses JUnit XML reports and replays them into a Report Portal launch
+// with original start and end times
+package junit
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skudasov/rpgoclient"
+)
+
+// timeFormat is RFC3339 with milliseconds, JUnit durations are usually shorter than a second
+const timeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// timestampFormats are formats of testsuite timestamp attribute, timestamps without zone are local
+var timestampFormats = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// Suite is a testsuite element, suites may be nested
+type Suite struct {
+	Name      string  `xml:"name,attr"`
+	Timestamp string  `xml:"timestamp,attr"`
+	Time      string  `xml:"time,attr"`
+	Suites    []Suite `xml:"testsuite"`
+	Cases     []Case  `xml:"testcase"`
+	SystemOut string  `xml:"system-out"`
+	SystemErr string  `xml:"system-err"`
+}
+
+// Case is a testcase element
+type Case struct {
+	Name      string   `xml:"name,attr"`
+	Classname string   `xml:"classname,attr"`
+	Time      string   `xml:"time,attr"`
+	Failures  []Result `xml:"failure"`
+	Errors    []Result `xml:"error"`
+	Skipped   *Result  `xml:"skipped"`
+	SystemOut string   `xml:"system-out"`
+	SystemErr string   `xml:"system-err"`
+}
+
+// Result is a failure, error or skipped element
+type Result struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// Status returns Report Portal status of the case
+func (tc Case) Status() string {
+	switch {
+	case len(tc.Failures) > 0 || len(tc.Errors) > 0:
+		return "FAILED"
+	case tc.Skipped != nil:
+		return "SKIPPED"
+	}
+	return "PASSED"
+}
+
+// Parse parses report with testsuites or testsuite root element
+func Parse(r io.Reader) ([]Suite, error) {
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("junit: no testsuites or testsuite element: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "testsuites":
+			var root struct {
+				Suites []Suite `xml:"testsuite"`
+			}
+			if err := dec.DecodeElement(&root, &start); err != nil {
+				return nil, err
+			}
+			return root.Suites, nil
+		case "testsuite":
+			var s Suite
+			if err := dec.DecodeElement(&s, &start); err != nil {
+				return nil, err
+			}
+			return []Suite{s}, nil
+		}
+		return nil, fmt.Errorf("junit: unexpected root element %s", start.Name.Local)
+	}
+}
+
+// ParseFile parses report file
+func ParseFile(path string) ([]Suite, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	suites, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return suites, nil
+}
+
+// Earliest returns earliest suite timestamp, zero time if suites have no timestamps.
+// Launch must not start later than its items
+func Earliest(suites []Suite) time.Time {
+	var res time.Time
+	for _, s := range suites {
+		for _, t := range []time.Time{parseTimestamp(s.Timestamp), Earliest(s.Suites)} {
+			if !t.IsZero() && (res.IsZero() || t.Before(res)) {
+				res = t
+			}
+		}
+	}
+	return res
+}
+
+// Report reports suites into current launch of the client, started with StartLaunch,
+// suites without timestamp start after previous suite and cases run one after another
+func Report(ctx context.Context, c *rpgoclient.Client, suites []Suite) (failed bool, err error) {
+	r := &reporter{c: c}
+	base := time.Now()
+	for _, s := range suites {
+		end, f, err := r.suite(ctx, "", s, base)
+		if err != nil {
+			return failed, err
+		}
+		failed = failed || f
+		base = end
+	}
+	return failed, nil
+}
+
+type reporter struct {
+	c *rpgoclient.Client
+}
+
+func (r *reporter) suite(ctx context.Context, parentId string, s Suite, start time.Time) (time.Time, bool, error) {
+	if t := parseTimestamp(s.Timestamp); !t.IsZero() {
+		start = t
+	}
+	resp, err := r.c.StartTestItemIdContext(ctx, parentId, s.Name, "SUITE", start.Format(timeFormat), "", nil, nil)
+	if err != nil {
+		return start, false, err
+	}
+	id := resp.Id
+	var failed bool
+	end := start
+	for _, child := range s.Suites {
+		childEnd, f, err := r.suite(ctx, id, child, end)
+		if err != nil {
+			return end, failed, err
+		}
+		failed = failed || f
+		end = childEnd
+	}
+	for _, tc := range s.Cases {
+		caseEnd, err := r.testCase(ctx, id, tc, end)
+		if err != nil {
+			return end, failed, err
+		}
+		failed = failed || tc.Status() == "FAILED"
+		end = caseEnd
+	}
+	if d := parseDuration(s.Time); start.Add(d).After(end) {
+		end = start.Add(d)
+	}
+	if err := r.log(ctx, id, outputLogs(start, s.SystemOut, s.SystemErr)); err != nil {
+		return end, failed, err
+	}
+	status := "PASSED"
+	if failed {
+		status = "FAILED"
+	}
+	_, err = r.c.FinishTestItemIdContext(ctx, id, status, end.Format(timeFormat), nil)
+	return end, failed, err
+}
+
+func (r *reporter) testCase(ctx context.Context, parentId string, tc Case, start time.Time) (time.Time, error) {
+	end := start.Add(parseDuration(tc.Time))
+	resp, err := r.c.StartTestItemIdContext(ctx, parentId, tc.Name, "TEST", start.Format(timeFormat), tc.Classname, nil, nil)
+	if err != nil {
+		return end, err
+	}
+	logs := outputLogs(start, tc.SystemOut, tc.SystemErr)
+	for _, res := range tc.Failures {
+		logs = append(logs, resultLog(end, "ERROR", res))
+	}
+	for _, res := range tc.Errors {
+		logs = append(logs, resultLog(end, "ERROR", res))
+	}
+	if tc.Skipped != nil && (tc.Skipped.Message != "" || strings.TrimSpace(tc.Skipped.Text) != "") {
+		logs = append(logs, resultLog(end, "INFO", *tc.Skipped))
+	}
+	if err := r.log(ctx, resp.Id, logs); err != nil {
+		return end, err
+	}
+	_, err = r.c.FinishTestItemIdContext(ctx, resp.Id, tc.Status(), end.Format(timeFormat), nil)
+	return end, err
+}
+
+func (r *reporter) log(ctx context.Context, itemId string, logs []rpgoclient.LogPayload) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	for i := range logs {
+		logs[i].ItemId = itemId
+	}
+	return r.c.LogBatchContext(ctx, logs)
+}
+
+func outputLogs(t time.Time, stdout string, stderr string) []rpgoclient.LogPayload {
+	var logs []rpgoclient.LogPayload
+	if s := strings.TrimSpace(stdout); s != "" {
+		logs = append(logs, rpgoclient.LogPayload{Time: t.Format(timeFormat), Message: s, Level: "INFO"})
+	}
+	if s := strings.TrimSpace(stderr); s != "" {
+		logs = append(logs, rpgoclient.LogPayload{Time: t.Format(timeFormat), Message: s, Level: "WARN"})
+	}
+	return logs
+}
+
+func resultLog(t time.Time, level string, res Result) rpgoclient.LogPayload {
+	msg := res.Message
+	if res.Type != "" {
+		msg = res.Type + ": " + msg
+	}
+	if text := strings.TrimSpace(res.Text); text != "" {
+		msg = strings.TrimSpace(msg + "\n" + text)
+	}
+	return rpgoclient.LogPayload{Time: t.Format(timeFormat), Message: msg, Level: level}
+}
+
+func parseTimestamp(s string) time.Time {
+	for _, f := range timestampFormats {
+		if t, err := time.ParseInLocation(f, s, time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// parseDuration parses time attribute in seconds, some tools write thousands separators
+func parseDuration(s string) time.Duration {
+	secs, err := strconv.ParseFloat(strings.Replace(s, ",", "", -1), 64)
+	if err != nil || secs < 0 {
+		return 0
+	}
+	return time.Duration(secs * float64(time.Second))
+}
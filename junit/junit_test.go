@@ -0,0 +1,120 @@
+package junit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/skudasov/rpgoclient"
+	"github.com/stretchr/testify/assert"
+)
+
+const report = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="com.example.CalcTest" timestamp="2021-03-01T10:00:00Z" time="1,500.5">
+    <testcase name="add" classname="com.example.CalcTest" time="0.25">
+      <system-out>adding</system-out>
+    </testcase>
+    <testcase name="div" classname="com.example.CalcTest" time="1">
+      <failure message="expected 2" type="AssertionError">at CalcTest.java:10</failure>
+    </testcase>
+    <testcase name="mul" classname="com.example.CalcTest">
+      <skipped message="not implemented"/>
+    </testcase>
+    <testsuite name="nested" timestamp="2021-03-01T10:00:05Z">
+      <testcase name="inner" time="0.5"/>
+    </testsuite>
+  </testsuite>
+</testsuites>`
+
+func TestParse(t *testing.T) {
+	suites, err := Parse(strings.NewReader(report))
+	assert.NoError(t, err)
+	if assert.Len(t, suites, 1) {
+		s := suites[0]
+		assert.Equal(t, "com.example.CalcTest", s.Name)
+		assert.Len(t, s.Cases, 3)
+		assert.Equal(t, []string{"PASSED", "FAILED", "SKIPPED"}, []string{s.Cases[0].Status(), s.Cases[1].Status(), s.Cases[2].Status()})
+		assert.Equal(t, "nested", s.Suites[0].Name)
+	}
+	assert.Equal(t, time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC), Earliest(suites).UTC())
+	assert.Equal(t, 1500500*time.Millisecond, parseDuration("1,500.5"))
+
+	single, err := Parse(strings.NewReader(`<testsuite name="single"><testcase name="a"/></testsuite>`))
+	assert.NoError(t, err)
+	assert.Equal(t, "single", single[0].Name)
+
+	_, err = Parse(strings.NewReader(`<html/>`))
+	assert.Error(t, err)
+}
+
+func TestReport(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	var logs []rpgoclient.LogPayload
+	var n int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/log"):
+			mr, _ := r.MultipartReader()
+			p, _ := mr.NextPart()
+			var batch []rpgoclient.LogPayload
+			_ = json.NewDecoder(p).Decode(&batch)
+			logs = append(logs, batch...)
+			requests = append(requests, fmt.Sprintf("log %d", len(batch)))
+		case r.Method == "POST":
+			var p rpgoclient.StartTestItemPayload
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			requests = append(requests, fmt.Sprintf("start %s %s %s", r.URL.Path, p.Name, p.StartTime))
+			n++
+			_, _ = fmt.Fprintf(w, `{"id": "id%d"}`, n)
+			return
+		case r.Method == "PUT":
+			var p rpgoclient.FinishTestItemPayload
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			requests = append(requests, fmt.Sprintf("finish %s %s %s", r.URL.Path, p.Status, p.EndTime))
+		}
+		_, _ = w.Write([]byte(`{"msg": "ok", "responses": []}`))
+	}))
+	defer ts.Close()
+	suites, err := Parse(strings.NewReader(report))
+	assert.NoError(t, err)
+	c := rpgoclient.New(ts.URL, "proj", "token", "", false, rpgoclient.WithVerbosity("error"))
+	_, err = c.StartLaunch("junit", "", Earliest(suites).Format(time.RFC3339), nil, "DEFAULT")
+	assert.NoError(t, err)
+	failed, err := Report(context.Background(), c, suites)
+	assert.NoError(t, err)
+	assert.True(t, failed)
+	assert.Equal(t, []string{
+		"start /api/v1/proj/launch junit 2021-03-01T10:00:00Z",
+		"start /api/v1/proj/item com.example.CalcTest 2021-03-01T10:00:00.000Z",
+		"start /api/v1/proj/item/id2 nested 2021-03-01T10:00:05.000Z",
+		"start /api/v1/proj/item/id3 inner 2021-03-01T10:00:05.000Z",
+		"finish /api/v1/proj/item/id4 PASSED 2021-03-01T10:00:05.500Z",
+		"finish /api/v1/proj/item/id3 PASSED 2021-03-01T10:00:05.500Z",
+		"start /api/v1/proj/item/id2 add 2021-03-01T10:00:05.500Z",
+		"log 1",
+		"finish /api/v1/proj/item/id5 PASSED 2021-03-01T10:00:05.750Z",
+		"start /api/v1/proj/item/id2 div 2021-03-01T10:00:05.750Z",
+		"log 1",
+		"finish /api/v1/proj/item/id6 FAILED 2021-03-01T10:00:06.750Z",
+		"start /api/v1/proj/item/id2 mul 2021-03-01T10:00:06.750Z",
+		"log 1",
+		"finish /api/v1/proj/item/id7 SKIPPED 2021-03-01T10:00:06.750Z",
+		"finish /api/v1/proj/item/id2 FAILED 2021-03-01T10:25:00.500Z",
+	}, requests)
+	if assert.Len(t, logs, 3) {
+		assert.Equal(t, rpgoclient.LogPayload{ItemId: "id5", Time: "2021-03-01T10:00:05.500Z", Message: "adding", Level: "INFO"}, logs[0])
+		assert.Equal(t, "AssertionError: expected 2\nat CalcTest.java:10", logs[1].Message)
+		assert.Equal(t, "ERROR", logs[1].Level)
+		assert.Equal(t, "not implemented", logs[2].Message)
+	}
+}
@@ -0,0 +1,213 @@
+package rpgoclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Timestamp is time in Report Portal responses, given either as epoch millis or as RFC3339 string
+type Timestamp struct {
+	time.Time
+}
+
+func (t *Timestamp) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if s == "null" {
+		return nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		var str string
+		if err := json.Unmarshal(b, &str); err != nil {
+			return err
+		}
+		if str == "" {
+			return nil
+		}
+		tt, err := time.Parse(time.RFC3339, str)
+		t.Time = tt
+		return err
+	}
+	ms, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return err
+	}
+	t.Time = time.Unix(0, ms*int64(time.Millisecond))
+	return nil
+}
+
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)), nil
+}
+
+// Statistics is executions counters, e.g. total, passed, failed, skipped,
+// and defects counters grouped by defect type, e.g. product_bug: {total: 1, PB001: 1}
+type Statistics struct {
+	Executions map[string]int            `json:"executions"`
+	Defects    map[string]map[string]int `json:"defects"`
+}
+
+// LaunchResource is a launch read from Report Portal, it is named so to not clash with Launch handle
+type LaunchResource struct {
+	Id                  int         `json:"id"`
+	Uuid                string      `json:"uuid"`
+	Name                string      `json:"name"`
+	Number              int         `json:"number"`
+	Description         string      `json:"description"`
+	Owner               string      `json:"owner"`
+	Status              string      `json:"status"`
+	Mode                string      `json:"mode"`
+	StartTime           Timestamp   `json:"startTime"`
+	EndTime             Timestamp   `json:"endTime"`
+	LastModified        Timestamp   `json:"lastModified"`
+	Attributes          []Attribute `json:"attributes"`
+	Statistics          Statistics  `json:"statistics"`
+	HasRetries          bool        `json:"hasRetries"`
+	ApproximateDuration float64     `json:"approximateDuration"`
+}
+
+// PageInfo describes returned page
+type PageInfo struct {
+	Number        int `json:"number"`
+	Size          int `json:"size"`
+	TotalElements int `json:"totalElements"`
+	TotalPages    int `json:"totalPages"`
+}
+
+type LaunchPage struct {
+	Content []LaunchResource `json:"content"`
+	Page    PageInfo         `json:"page"`
+}
+
+// Page selects page of results, Number starts from 1, zero values use server defaults,
+// Sort is field and optional direction, e.g. "startTime,DESC"
+type Page struct {
+	Number int
+	Size   int
+	Sort   []string
+}
+
+func (p Page) values(v url.Values) {
+	if p.Number > 0 {
+		v.Set("page.page", strconv.Itoa(p.Number))
+	}
+	if p.Size > 0 {
+		v.Set("page.size", strconv.Itoa(p.Size))
+	}
+	for _, s := range p.Sort {
+		v.Add("page.sort", s)
+	}
+}
+
+// Filter builds Report Portal filter query, conditions are combined with AND
+//
+//	NewFilter().Eq("name", "nightly").Has("compositeAttribute", "branch:master").Gte("startTime", "1614556800000")
+type Filter struct {
+	v url.Values
+}
+
+func NewFilter() *Filter {
+	return &Filter{v: make(url.Values)}
+}
+
+// Cond adds condition filter.<condition>.<field>=value, use it for conditions without helper
+func (f *Filter) Cond(condition string, field string, value string) *Filter {
+	f.v.Add(fmt.Sprintf("filter.%s.%s", condition, field), value)
+	return f
+}
+
+// Eq matches field equal to value
+func (f *Filter) Eq(field string, value string) *Filter {
+	return f.Cond("eq", field, value)
+}
+
+// Ne matches field not equal to value
+func (f *Filter) Ne(field string, value string) *Filter {
+	return f.Cond("ne", field, value)
+}
+
+// Cnt matches field containing value
+func (f *Filter) Cnt(field string, value string) *Filter {
+	return f.Cond("cnt", field, value)
+}
+
+// Has matches collection field, e.g. attributes, containing all values
+func (f *Filter) Has(field string, values ...string) *Filter {
+	return f.Cond("has", field, strings.Join(values, ","))
+}
+
+// In matches field equal to one of values
+func (f *Filter) In(field string, values ...string) *Filter {
+	return f.Cond("in", field, strings.Join(values, ","))
+}
+
+// Gte matches field greater than or equal to value, times are given in epoch millis
+func (f *Filter) Gte(field string, value string) *Filter {
+	return f.Cond("gte", field, value)
+}
+
+// Lte matches field less than or equal to value, times are given in epoch millis
+func (f *Filter) Lte(field string, value string) *Filter {
+	return f.Cond("lte", field, value)
+}
+
+// Values returns filter query parameters
+func (f *Filter) Values() url.Values {
+	v := make(url.Values, len(f.v))
+	for k, vs := range f.v {
+		v[k] = append([]string(nil), vs...)
+	}
+	return v
+}
+
+// query returns filter and page query, nil filter matches everything
+func query(filter *Filter, page Page) url.Values {
+	v := make(url.Values)
+	if filter != nil {
+		v = filter.Values()
+	}
+	page.values(v)
+	return v
+}
+
+// ListLaunches returns page of project launches matching filter, filter may be nil
+func (c *Client) ListLaunches(ctx context.Context, filter *Filter, page Page) (LaunchPage, error) {
+	var respBody LaunchPage
+	err := c.get(ctx, fmt.Sprintf("%s/%s/launch", c.apiV1URL(), c.Project), query(filter, page), &respBody)
+	if err != nil {
+		return LaunchPage{}, err
+	}
+	c.l.Debugf("listed launches: %d of %d", len(respBody.Content), respBody.Page.TotalElements)
+	return respBody, nil
+}
+
+// GetLaunch returns launch by numeric id or uuid
+func (c *Client) GetLaunch(ctx context.Context, id string) (LaunchResource, error) {
+	u := fmt.Sprintf("%s/%s/launch/%s", c.apiV1URL(), c.Project, id)
+	if _, err := strconv.Atoi(id); err != nil {
+		u = fmt.Sprintf("%s/%s/launch/uuid/%s", c.apiV1URL(), c.Project, id)
+	}
+	var respBody LaunchResource
+	if err := c.get(ctx, u, nil, &respBody); err != nil {
+		return LaunchResource{}, err
+	}
+	return respBody, nil
+}
+
+// get performs GET request with query and decodes response into v
+func (c *Client) get(ctx context.Context, path string, q url.Values, v interface{}) error {
+	req, err := c.newRequest(ctx, "GET", path, nil, "")
+	if err != nil {
+		return err
+	}
+	req.URL.RawQuery = q.Encode()
+	_, err = c.do(req, v)
+	return err
+}
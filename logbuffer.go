@@ -0,0 +1,177 @@
+package rpgoclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+// logBuffer accumulates log messages per item and sends them with LogBatch when count or size threshold is reached,
+// on interval, when item or launch is finished and on Flush
+type logBuffer struct {
+	c        *Client
+	maxCount int
+	maxBytes int64
+	interval time.Duration
+
+	mu    sync.Mutex
+	items map[string]*itemLogs
+	count int
+	size  int64
+	errs  error
+
+	// sendMu keeps batches in the order messages were buffered
+	sendMu sync.Mutex
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+type itemLogs struct {
+	launchId string
+	logs     []LogPayload
+}
+
+// WithLogBuffer buffers Log and LogId messages and sends them in batches when maxCount messages or maxBytes
+// are buffered, every interval and before item or launch is finished, zero disables the threshold
+func WithLogBuffer(maxCount int, maxBytes int64, interval time.Duration) func(client *Client) error {
+	return func(c *Client) error {
+		if maxCount < 0 || maxBytes < 0 || interval < 0 {
+			return fmt.Errorf("log buffer thresholds must not be negative")
+		}
+		c.logBuf = &logBuffer{
+			c:        c,
+			maxCount: maxCount,
+			maxBytes: maxBytes,
+			interval: interval,
+			items:    make(map[string]*itemLogs),
+		}
+		return nil
+	}
+}
+
+// startTicker starts periodic flushing, errors are collected and returned by Client.Flush
+func (b *logBuffer) startTicker() {
+	if b.interval <= 0 {
+		return
+	}
+	b.stop = make(chan struct{})
+	b.done = make(chan struct{})
+	go func() {
+		defer close(b.done)
+		t := time.NewTicker(b.interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				if err := b.flush(context.Background(), ""); err != nil {
+					b.c.l.Errorf("log buffer flush failed: %s", err)
+					b.mu.Lock()
+					b.errs = multierr.Append(b.errs, err)
+					b.mu.Unlock()
+				}
+			case <-b.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (b *logBuffer) stopTicker() {
+	if b.stop == nil {
+		return
+	}
+	select {
+	case <-b.stop:
+	default:
+		close(b.stop)
+	}
+	<-b.done
+}
+
+// add buffers message and flushes buffer if threshold is reached
+func (b *logBuffer) add(ctx context.Context, launchId string, p LogPayload) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	il, ok := b.items[p.ItemId]
+	if !ok {
+		il = &itemLogs{launchId: launchId}
+		b.items[p.ItemId] = il
+	}
+	il.logs = append(il.logs, p)
+	b.count++
+	b.size += int64(len(data))
+	full := (b.maxCount > 0 && b.count >= b.maxCount) || (b.maxBytes > 0 && b.size >= b.maxBytes)
+	b.mu.Unlock()
+	if full {
+		return b.flush(ctx, "")
+	}
+	return nil
+}
+
+// flushItem sends buffered messages of the item
+func (b *logBuffer) flushItem(ctx context.Context, itemId string) error {
+	b.sendMu.Lock()
+	defer b.sendMu.Unlock()
+	b.mu.Lock()
+	il, ok := b.items[itemId]
+	if ok {
+		b.remove(itemId, il)
+	}
+	b.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return b.c.logBatch(ctx, il.launchId, il.logs, nil)
+}
+
+// flush sends buffered messages of the launch or of all launches if launchId is empty, batched by launch
+func (b *logBuffer) flush(ctx context.Context, launchId string) error {
+	b.sendMu.Lock()
+	defer b.sendMu.Unlock()
+	b.mu.Lock()
+	byLaunch := make(map[string][]LogPayload)
+	var launches []string
+	for id, il := range b.items {
+		if launchId != "" && il.launchId != launchId {
+			continue
+		}
+		if _, ok := byLaunch[il.launchId]; !ok {
+			launches = append(launches, il.launchId)
+		}
+		byLaunch[il.launchId] = append(byLaunch[il.launchId], il.logs...)
+		b.remove(id, il)
+	}
+	b.mu.Unlock()
+	var errs error
+	for _, id := range launches {
+		errs = multierr.Append(errs, b.c.logBatch(ctx, id, byLaunch[id], nil))
+	}
+	return errs
+}
+
+// remove must be called with b.mu held
+func (b *logBuffer) remove(itemId string, il *itemLogs) {
+	for _, p := range il.logs {
+		data, _ := json.Marshal(p)
+		b.size -= int64(len(data))
+	}
+	b.count -= len(il.logs)
+	delete(b.items, itemId)
+}
+
+// takeErrors returns and resets errors of background flushes
+func (b *logBuffer) takeErrors() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	err := b.errs
+	b.errs = nil
+	return err
+}
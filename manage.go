@@ -0,0 +1,143 @@
+package rpgoclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OperationResponse is result message of launch management request
+type OperationResponse struct {
+	Message string `json:"message"`
+}
+
+// DeleteLaunchesResponse lists deleted and not found launch ids, Errors are launches which can not be deleted,
+// e.g. launches in progress
+type DeleteLaunchesResponse struct {
+	SuccessfullyDeleted []int `json:"successfullyDeleted"`
+	NotFound            []int `json:"notFound"`
+	Errors              []struct {
+		ErrorCode int    `json:"errorCode"`
+		Message   string `json:"message"`
+	} `json:"errors"`
+}
+
+// ImportLaunchResponse is result of launch import, LaunchId is uuid of imported launch
+type ImportLaunchResponse struct {
+	Message  string `json:"message"`
+	LaunchId string `json:"-"`
+}
+
+type stopLaunchPayload struct {
+	Status  string `json:"status"`
+	EndTime int64  `json:"endTime"`
+}
+
+type updateLaunchPayload struct {
+	Mode        string      `json:"mode,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Attributes  []Attribute `json:"attributes,omitempty"`
+}
+
+// StopLaunch force finishes launch by numeric id or uuid with STOPPED status, unfinished items are stopped too,
+// it is used for launches left IN_PROGRESS by crashed runners
+func (c *Client) StopLaunch(ctx context.Context, launchId string) (OperationResponse, error) {
+	id, err := c.numericLaunchId(ctx, launchId)
+	if err != nil {
+		return OperationResponse{}, err
+	}
+	p := stopLaunchPayload{Status: "STOPPED", EndTime: time.Now().UnixNano() / int64(time.Millisecond)}
+	var respBody OperationResponse
+	if err := c.send(ctx, "PUT", fmt.Sprintf("%s/%s/launch/%s/stop", c.apiV1URL(), c.Project, id), p, &respBody); err != nil {
+		return OperationResponse{}, err
+	}
+	c.l.Debugf("launch stopped: %s", launchId)
+	return respBody, nil
+}
+
+// DeleteLaunches deletes finished launches by numeric ids
+func (c *Client) DeleteLaunches(ctx context.Context, ids []int) (DeleteLaunchesResponse, error) {
+	strIds := make([]string, 0, len(ids))
+	for _, id := range ids {
+		strIds = append(strIds, strconv.Itoa(id))
+	}
+	req, err := c.newRequest(ctx, "DELETE", fmt.Sprintf("%s/%s/launch", c.apiV1URL(), c.Project), nil, "")
+	if err != nil {
+		return DeleteLaunchesResponse{}, err
+	}
+	req.URL.RawQuery = url.Values{"ids": {strings.Join(strIds, ",")}}.Encode()
+	var respBody DeleteLaunchesResponse
+	if _, err := c.do(req, &respBody); err != nil {
+		return DeleteLaunchesResponse{}, err
+	}
+	c.l.Debugf("launches deleted: %v, not found: %v", respBody.SuccessfullyDeleted, respBody.NotFound)
+	return respBody, nil
+}
+
+// UpdateLaunch updates description, tags and mode, DEFAULT or DEBUG, of launch by numeric id or uuid,
+// empty values are left unchanged, tags replace launch attributes, "key:value" tags become attributes with key
+func (c *Client) UpdateLaunch(ctx context.Context, launchId string, description string, tags []string, mode string) (OperationResponse, error) {
+	id, err := c.numericLaunchId(ctx, launchId)
+	if err != nil {
+		return OperationResponse{}, err
+	}
+	p := updateLaunchPayload{Mode: mode, Description: description}
+	if tags != nil {
+		p.Attributes = tagsToAttributes(tags)
+	}
+	var respBody OperationResponse
+	if err := c.send(ctx, "PUT", fmt.Sprintf("%s/%s/launch/%s/update", c.apiV1URL(), c.Project, id), p, &respBody); err != nil {
+		return OperationResponse{}, err
+	}
+	c.l.Debugf("launch updated: %s", launchId)
+	return respBody, nil
+}
+
+// ImportLaunch uploads zip archive of JUnit xml reports as a new launch named after the file,
+// r is streamed into request, if it is io.Seeker request can be retried
+func (c *Client) ImportLaunch(ctx context.Context, fileName string, r io.Reader) (ImportLaunchResponse, error) {
+	body, err := newMultipartBody(nil, []Attachment{{Name: fileName, ContentType: "application/zip", Reader: r}})
+	if err != nil {
+		return ImportLaunchResponse{}, err
+	}
+	req, err := c.multipartRequest(ctx, fmt.Sprintf("%s/%s/launch/import", c.apiV1URL(), c.Project), body)
+	if err != nil {
+		return ImportLaunchResponse{}, err
+	}
+	defer body.close()
+	var respBody ImportLaunchResponse
+	if _, err := c.do(req, &respBody); err != nil {
+		return ImportLaunchResponse{}, err
+	}
+	respBody.LaunchId = importedLaunchId(respBody.Message)
+	c.l.Debugf("launch imported: %s", respBody.LaunchId)
+	return respBody, nil
+}
+
+// importedLaunchId parses launch uuid from message "Launch with id = <uuid> is successfully imported."
+func importedLaunchId(message string) string {
+	const prefix = "id = "
+	i := strings.Index(message, prefix)
+	if i < 0 {
+		return ""
+	}
+	fields := strings.Fields(message[i+len(prefix):])
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// send performs request with json body and decodes response into v
+func (c *Client) send(ctx context.Context, method string, path string, body interface{}, v interface{}) error {
+	req, err := c.newRequest(ctx, method, path, body, "application/json")
+	if err != nil {
+		return err
+	}
+	_, err = c.do(req, v)
+	return err
+}
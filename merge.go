@@ -0,0 +1,136 @@
+package rpgoclient
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Merge types, basic merge puts items of all launches side by side, deep merge joins suites with the same name
+const (
+	MergeBasic = "BASIC"
+	MergeDeep  = "DEEP"
+)
+
+type MergeLaunchesPayload struct {
+	Launches    []int       `json:"launches"`
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Attributes  []Attribute `json:"attributes,omitempty"`
+	StartTime   *Timestamp  `json:"startTime,omitempty"`
+	EndTime     *Timestamp  `json:"endTime,omitempty"`
+	Mode        string      `json:"mode,omitempty"`
+	MergeType   string      `json:"mergeType"`
+	// ExtendSuitesDescription adds name and number of the source launch to descriptions of its suites
+	ExtendSuitesDescription bool `json:"extendSuitesDescription"`
+}
+
+// MergeLaunches merges finished launches by numeric ids into a new launch, source launches are removed
+func (c *Client) MergeLaunches(ctx context.Context, ids []int, name string, mergeType string, extendSuitesDescription bool) (LaunchResource, error) {
+	return c.mergeLaunches(ctx, MergeLaunchesPayload{
+		Launches:                ids,
+		Name:                    name,
+		MergeType:               mergeType,
+		ExtendSuitesDescription: extendSuitesDescription,
+	})
+}
+
+func (c *Client) mergeLaunches(ctx context.Context, p MergeLaunchesPayload) (LaunchResource, error) {
+	c.l.Debugf("merging launches %v into: %s", p.Launches, p.Name)
+	var respBody LaunchResource
+	if err := c.send(ctx, "POST", fmt.Sprintf("%s/%s/launch/merge", c.apiV1URL(), c.Project), p, &respBody); err != nil {
+		return LaunchResource{}, err
+	}
+	c.l.Debugf("launches merged into: %d", respBody.Id)
+	return respBody, nil
+}
+
+// MergeShards waits until shards launches with attribute, "key:value" or value, are finished and merges them
+// into launch named name with attributes of the shards, except attribute itself, and their overall start and end time.
+// Launches are polled every interval until ctx is done
+func (c *Client) MergeShards(ctx context.Context, attribute string, shards int, name string, mergeType string, interval time.Duration) (LaunchResource, error) {
+	if shards < 1 {
+		return LaunchResource{}, fmt.Errorf("shards must be positive, got %d", shards)
+	}
+	if interval <= 0 {
+		return LaunchResource{}, fmt.Errorf("poll interval must be positive, got %s", interval)
+	}
+	filter := NewFilter().Has("compositeAttribute", attribute)
+	for {
+		launches, err := c.listAllLaunches(ctx, filter)
+		if err != nil {
+			return LaunchResource{}, err
+		}
+		if len(launches) > shards {
+			return LaunchResource{}, fmt.Errorf("found %d launches with attribute %s, expected %d shards", len(launches), attribute, shards)
+		}
+		if len(launches) == shards && allFinished(launches) {
+			return c.mergeLaunches(ctx, shardsMergePayload(launches, attribute, name, mergeType))
+		}
+		c.l.Debugf("waiting for shards with attribute %s: %d of %d started", attribute, len(launches), shards)
+		if err := sleepContext(ctx, interval); err != nil {
+			return LaunchResource{}, err
+		}
+	}
+}
+
+func (c *Client) listAllLaunches(ctx context.Context, filter *Filter) ([]LaunchResource, error) {
+	var res []LaunchResource
+	for page := (Page{Number: 1, Size: 100}); ; page.Number++ {
+		p, err := c.ListLaunches(ctx, filter, page)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, p.Content...)
+		if len(p.Content) == 0 || p.Page.Number >= p.Page.TotalPages {
+			return res, nil
+		}
+	}
+}
+
+func allFinished(launches []LaunchResource) bool {
+	for _, l := range launches {
+		if l.Status == "IN_PROGRESS" {
+			return false
+		}
+	}
+	return true
+}
+
+// shardsMergePayload merges shards attributes, the attribute shards were found by is dropped,
+// so the merged launch is not found as a shard itself
+func shardsMergePayload(launches []LaunchResource, attribute string, name string, mergeType string) MergeLaunchesPayload {
+	p := MergeLaunchesPayload{Name: name, MergeType: mergeType, Mode: "DEFAULT"}
+	var start, end Timestamp
+	seen := make(map[Attribute]bool)
+	for _, l := range launches {
+		p.Launches = append(p.Launches, l.Id)
+		if start.IsZero() || l.StartTime.Before(start.Time) {
+			start = l.StartTime
+		}
+		if l.EndTime.After(end.Time) {
+			end = l.EndTime
+		}
+		for _, a := range l.Attributes {
+			if !seen[a] && compositeAttribute(a) != attribute {
+				seen[a] = true
+				p.Attributes = append(p.Attributes, a)
+			}
+		}
+	}
+	if !start.IsZero() {
+		p.StartTime = &start
+	}
+	if !end.IsZero() {
+		p.EndTime = &end
+	}
+	return p
+}
+
+// compositeAttribute returns attribute as "key:value", or value if it has no key
+func compositeAttribute(a Attribute) string {
+	if a.Key == "" {
+		return a.Value
+	}
+	return a.Key + ":" + a.Value
+}
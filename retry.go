@@ -0,0 +1,149 @@
+package rpgoclient
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy describes which failed requests are retried and how long to wait between attempts
+type RetryPolicy struct {
+	// MaxRetries is a number of retries after the first attempt
+	MaxRetries int
+	// MinBackoff is a delay before the first retry, it grows by Multiplier on every next retry up to MaxBackoff,
+	// MaxBackoff also bounds delay requested by server with Retry-After
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	Multiplier float64
+	// Jitter randomizes backoff by given fraction, 0.2 means +-20%
+	Jitter float64
+	// RetryableStatus reports whether response with status code should be retried
+	RetryableStatus func(code int) bool
+	// IdempotentMethods are retried on any network error and retryable status, so are v2 starts of launches
+	// and items, which server deduplicates by client side uuid. Other methods are retried only if request
+	// was not processed by server: connection was not established or server responded with 429, 502, 503 or 504
+	IdempotentMethods map[string]bool
+}
+
+// DefaultRetryPolicy backs off exponentially on network errors, 429 and 5xx, client errors are not retried
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries: 3,
+		MinBackoff: 100 * time.Millisecond,
+		MaxBackoff: 10 * time.Second,
+		Multiplier: 2,
+		Jitter:     0.2,
+		RetryableStatus: func(code int) bool {
+			return code == http.StatusTooManyRequests || (code >= 500 && code != http.StatusNotImplemented)
+		},
+		IdempotentMethods: map[string]bool{
+			http.MethodGet:     true,
+			http.MethodHead:    true,
+			http.MethodOptions: true,
+			http.MethodPut:     true,
+			http.MethodDelete:  true,
+		},
+	}
+}
+
+// WithRetryPolicy sets retry policy, MaxRetries of the policy overrides Client.Retries
+func WithRetryPolicy(policy RetryPolicy) func(client *Client) error {
+	return func(c *Client) error {
+		if policy.MaxRetries < 0 {
+			return errors.New("max retries must not be negative")
+		}
+		c.retryPolicy = policy
+		c.Retries = policy.MaxRetries
+		return nil
+	}
+}
+
+// retryError reports whether request failed with network error should be retried
+func (p RetryPolicy) retryError(idempotent bool, err error) bool {
+	if idempotent {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// retryStatus reports whether request failed with status code should be retried
+func (p RetryPolicy) retryStatus(idempotent bool, code int) bool {
+	if p.RetryableStatus == nil || !p.RetryableStatus(code) {
+		return false
+	}
+	if idempotent {
+		return true
+	}
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns delay before retry number attempt, starting from 0
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	mult := p.Multiplier
+	if mult < 1 {
+		mult = 1
+	}
+	d := float64(p.MinBackoff) * math.Pow(mult, float64(attempt))
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(d)
+}
+
+// clamp bounds delay requested by server with MaxBackoff, if it is set
+func (p RetryPolicy) clamp(d time.Duration) time.Duration {
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return d
+}
+
+// retryAfter parses Retry-After header given in seconds or as http date
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	h := resp.Header.Get("Retry-After")
+	if h == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(h); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(h); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
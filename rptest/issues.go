@@ -0,0 +1,197 @@
+package rptest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DefectType is defect type of project settings
+type DefectType struct {
+	Id        int    `json:"id"`
+	Locator   string `json:"locator"`
+	TypeRef   string `json:"typeRef"`
+	LongName  string `json:"longName"`
+	ShortName string `json:"shortName"`
+	Color     string `json:"color"`
+}
+
+func defaultDefectTypes() []DefectType {
+	return []DefectType{
+		{Id: 1, Locator: "TI001", TypeRef: "TO_INVESTIGATE", LongName: "To Investigate", ShortName: "TI", Color: "#ffb743"},
+		{Id: 2, Locator: "AB001", TypeRef: "AUTOMATION_BUG", LongName: "Automation Bug", ShortName: "AB", Color: "#f7d63e"},
+		{Id: 3, Locator: "PB001", TypeRef: "PRODUCT_BUG", LongName: "Product Bug", ShortName: "PB", Color: "#ec3900"},
+		{Id: 4, Locator: "ND001", TypeRef: "NO_DEFECT", LongName: "No Defect", ShortName: "ND", Color: "#777777"},
+		{Id: 5, Locator: "SI001", TypeRef: "SYSTEM_ISSUE", LongName: "System Issue", ShortName: "SI", Color: "#0274d1"},
+	}
+}
+
+// AddDefectType adds custom defect subtype of default typeRef group, e.g. PRODUCT_BUG, it returns locator of the subtype
+func (s *Server) AddDefectType(typeRef string, longName string, shortName string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextId++
+	var prefix string
+	for _, t := range s.defectTypes {
+		if t.TypeRef == typeRef {
+			prefix = t.Locator[:2]
+			break
+		}
+	}
+	t := DefectType{
+		Id:        len(s.defectTypes) + 1,
+		Locator:   fmt.Sprintf("%s_%d", prefix, s.nextId),
+		TypeRef:   typeRef,
+		LongName:  longName,
+		ShortName: shortName,
+		Color:     "#999999",
+	}
+	s.defectTypes = append(s.defectTypes, t)
+	return t.Locator
+}
+
+func (s *Server) defectType(locator string) (DefectType, bool) {
+	for _, t := range s.defectTypes {
+		if t.Locator == locator {
+			return t, true
+		}
+	}
+	return DefectType{}, false
+}
+
+func (s *Server) settings() (interface{}, *apiError) {
+	subTypes := make(map[string][]DefectType)
+	for _, t := range s.defectTypes {
+		subTypes[t.TypeRef] = append(subTypes[t.TypeRef], t)
+	}
+	return map[string]interface{}{"subTypes": subTypes}, nil
+}
+
+type defineIssueRQ struct {
+	Issues []struct {
+		TestItemId int                    `json:"testItemId"`
+		Issue      map[string]interface{} `json:"issue"`
+	} `json:"issues"`
+}
+
+// defineIssues sets issues of finished items, issue types must be defect types of the project
+func (s *Server) defineIssues(body []byte) (interface{}, *apiError) {
+	var rq defineIssueRQ
+	if err := decode(body, &rq); err != nil {
+		return nil, err
+	}
+	for _, d := range rq.Issues {
+		it := s.item(strconv.Itoa(d.TestItemId))
+		if it == nil {
+			return nil, errorf(http.StatusNotFound, ErrorCodeItemNotFound, "test item '%d' not found", d.TestItemId)
+		}
+		if !it.Finished() {
+			return nil, errorf(http.StatusBadRequest, ErrorCodeIncorrectRequest, "test item '%d' is not finished", d.TestItemId)
+		}
+		t, _ := d.Issue["issueType"].(string)
+		if _, ok := s.defectType(t); !ok {
+			return nil, errorf(http.StatusBadRequest, ErrorCodeIncorrectRequest, "incorrect request: unknown issue type '%s'", t)
+		}
+	}
+	res := make([]map[string]interface{}, 0, len(rq.Issues))
+	for _, d := range rq.Issues {
+		it := s.item(strconv.Itoa(d.TestItemId))
+		it.Issue = d.Issue
+		res = append(res, d.Issue)
+	}
+	return res, nil
+}
+
+type analyzeRQ struct {
+	LaunchId         int      `json:"launchId"`
+	AnalyzerMode     string   `json:"analyzerMode"`
+	AnalyzerTypeName string   `json:"analyzerTypeName"`
+	AnalyzeItemsMode []string `json:"analyzeItemsMode"`
+}
+
+// analyzeLaunch is fake auto-analyzer, it matches items by name instead of logs:
+// selected items of the launch get issue of the latest analyzed item with the same name in base launches
+func (s *Server) analyzeLaunch(body []byte) (interface{}, *apiError) {
+	var rq analyzeRQ
+	if err := decode(body, &rq); err != nil {
+		return nil, err
+	}
+	l := s.launch(strconv.Itoa(rq.LaunchId))
+	if l == nil {
+		return nil, errorf(http.StatusNotFound, ErrorCodeLaunchNotFound, "launch '%d' not found", rq.LaunchId)
+	}
+	if !l.Finished() {
+		return nil, errorf(http.StatusBadRequest, ErrorCodeIncorrectRequest, "launch '%d' is in progress", rq.LaunchId)
+	}
+	var base func(*Launch) bool
+	switch rq.AnalyzerMode {
+	case "ALL":
+		base = func(*Launch) bool { return true }
+	case "LAUNCH_NAME":
+		base = func(bl *Launch) bool { return bl.Name == l.Name }
+	case "CURRENT_LAUNCH":
+		base = func(bl *Launch) bool { return bl == l }
+	default:
+		return nil, errorf(http.StatusBadRequest, ErrorCodeIncorrectRequest, "incorrect request: unknown analyzer mode '%s'", rq.AnalyzerMode)
+	}
+	var targets []*Item
+	for _, it := range s.launchItems(l.Uuid) {
+		if it.Finished() && !s.hasChildren(it.Uuid) && analyzable(it, rq.AnalyzeItemsMode) {
+			targets = append(targets, it)
+		}
+	}
+	for _, it := range targets {
+		if issue := s.baseIssue(it, targets, base); issue != nil {
+			it.Issue = map[string]interface{}{"issueType": issue["issueType"], "comment": issue["comment"], "autoAnalyzed": true, "ignoreAnalyzer": false}
+		}
+	}
+	return map[string]interface{}{"message": fmt.Sprintf("Auto-analyzer for launch ID='%d' started.", l.Id)}, nil
+}
+
+// analyzable reports whether item matches one of analyze items modes
+func analyzable(it *Item, modes []string) bool {
+	if issueFlag(it, "ignoreAnalyzer") {
+		return false
+	}
+	t := issueType(it)
+	auto := issueFlag(it, "autoAnalyzed")
+	for _, m := range modes {
+		switch {
+		case m == "TO_INVESTIGATE" && strings.HasPrefix(t, "TI"):
+			return true
+		case m == "AUTO_ANALYZED" && auto:
+			return true
+		case m == "MANUALLY_ANALYZED" && t != "" && !strings.HasPrefix(t, "TI") && !auto:
+			return true
+		}
+	}
+	return false
+}
+
+// baseIssue returns issue of the latest item with the same name in base launches, which is not to investigate
+func (s *Server) baseIssue(it *Item, targets []*Item, base func(*Launch) bool) map[string]interface{} {
+	for i := len(s.items) - 1; i >= 0; i-- {
+		b := s.items[i]
+		if b == it || b.Name != it.Name || containsItem(targets, b) {
+			continue
+		}
+		if bl := s.launch(b.LaunchUuid); bl == nil || !base(bl) {
+			continue
+		}
+		t := issueType(b)
+		if _, ok := s.defectType(t); ok && !strings.HasPrefix(t, "TI") {
+			return map[string]interface{}{"issueType": t, "comment": b.Issue["comment"]}
+		}
+	}
+	return nil
+}
+
+func containsItem(items []*Item, it *Item) bool {
+	for _, i := range items {
+		if i == it {
+			return true
+		}
+	}
+	return false
+}
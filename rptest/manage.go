@@ -0,0 +1,267 @@
+package rptest
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type stopRQ struct {
+	Status  string `json:"status"`
+	EndTime rpTime `json:"endTime"`
+}
+
+// stopLaunch force finishes launch and its unfinished items
+func (s *Server) stopLaunch(id string, body []byte) (interface{}, *apiError) {
+	var rq stopRQ
+	if err := decode(body, &rq); err != nil {
+		return nil, err
+	}
+	l := s.launch(id)
+	if l == nil {
+		return nil, errorf(http.StatusNotFound, ErrorCodeLaunchNotFound, "launch '%s' not found", id)
+	}
+	if l.Finished() {
+		return nil, errorf(http.StatusNotAcceptable, ErrorCodeFinishNotAllowed, "launch '%s' is already finished", id)
+	}
+	end := rq.EndTime.Time
+	if end.IsZero() {
+		end = time.Now()
+	}
+	status := rq.Status
+	if status == "" {
+		status = "STOPPED"
+	}
+	for _, it := range s.launchItems(l.Uuid) {
+		if !it.Finished() {
+			it.EndTime = end
+			it.Status = status
+		}
+	}
+	l.EndTime = end
+	l.Status = status
+	return map[string]interface{}{"message": fmt.Sprintf("Launch with ID = '%d' successfully stopped.", l.Id)}, nil
+}
+
+// deleteLaunches deletes finished launches with their items and logs
+func (s *Server) deleteLaunches(q url.Values) (interface{}, *apiError) {
+	deleted := []int{}
+	notFound := []int{}
+	errs := []*apiError{}
+	for _, v := range strings.Split(q.Get("ids"), ",") {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, errorf(http.StatusBadRequest, ErrorCodeIncorrectRequest, "incorrect request: launch id '%s'", v)
+		}
+		l := s.launch(v)
+		if l == nil {
+			notFound = append(notFound, id)
+			continue
+		}
+		if !l.Finished() {
+			errs = append(errs, errorf(http.StatusNotAcceptable, ErrorCodeIncorrectRequest, "launch '%d' is in progress", id))
+			continue
+		}
+		s.deleteLaunch(l)
+		deleted = append(deleted, id)
+	}
+	return map[string]interface{}{"successfullyDeleted": deleted, "notFound": notFound, "errors": errs}, nil
+}
+
+func (s *Server) deleteLaunch(l *Launch) {
+	removed := make(map[string]bool)
+	items := make([]*Item, 0, len(s.items))
+	for _, it := range s.items {
+		if it.LaunchUuid == l.Uuid {
+			removed[it.Uuid] = true
+			continue
+		}
+		items = append(items, it)
+	}
+	s.items = items
+	logs := make([]*Log, 0, len(s.logs))
+	for _, lg := range s.logs {
+		if !removed[lg.ItemUuid] {
+			logs = append(logs, lg)
+		}
+	}
+	s.logs = logs
+	launches := make([]*Launch, 0, len(s.launches))
+	for _, ll := range s.launches {
+		if ll != l {
+			launches = append(launches, ll)
+		}
+	}
+	s.launches = launches
+}
+
+type updateRQ struct {
+	Mode        string      `json:"mode"`
+	Description *string     `json:"description"`
+	Attributes  []Attribute `json:"attributes"`
+}
+
+// updateLaunch updates mode, description and attributes given in request, attributes replace v1 tags
+func (s *Server) updateLaunch(id string, body []byte) (interface{}, *apiError) {
+	var rq updateRQ
+	if err := decode(body, &rq); err != nil {
+		return nil, err
+	}
+	l := s.launch(id)
+	if l == nil {
+		return nil, errorf(http.StatusNotFound, ErrorCodeLaunchNotFound, "launch '%s' not found", id)
+	}
+	switch rq.Mode {
+	case "":
+	case "DEFAULT", "DEBUG":
+		l.Mode = rq.Mode
+	default:
+		return nil, errorf(http.StatusBadRequest, ErrorCodeIncorrectRequest, "incorrect request: unknown mode '%s'", rq.Mode)
+	}
+	if rq.Description != nil {
+		l.Description = *rq.Description
+	}
+	if rq.Attributes != nil {
+		l.Attributes = rq.Attributes
+		l.Tags = nil
+	}
+	return map[string]interface{}{"message": fmt.Sprintf("Launch with ID = '%d' successfully updated.", l.Id)}, nil
+}
+
+// junitSuite is testsuite or testsuites element of JUnit report
+type junitSuite struct {
+	XMLName xml.Name
+	Name    string       `xml:"name,attr"`
+	Suites  []junitSuite `xml:"testsuite"`
+	Cases   []junitCase  `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name    string    `xml:"name,attr"`
+	Failure *struct{} `xml:"failure"`
+	Error   *struct{} `xml:"error"`
+	Skipped *struct{} `xml:"skipped"`
+}
+
+func (c junitCase) status() string {
+	switch {
+	case c.Failure != nil || c.Error != nil:
+		return "FAILED"
+	case c.Skipped != nil:
+		return "SKIPPED"
+	}
+	return "PASSED"
+}
+
+// importLaunch creates finished launch named after uploaded zip file from JUnit xml reports in it
+func (s *Server) importLaunch(r *http.Request, body []byte) (interface{}, *apiError) {
+	_, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	mr := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	f, err := mr.ReadForm(int64(len(body)))
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, ErrorCodeIncorrectRequest, "incorrect multipart request: %s", err)
+	}
+	defer f.RemoveAll()
+	if len(f.File["file"]) == 0 {
+		return nil, errorf(http.StatusBadRequest, ErrorCodeIncorrectRequest, "incorrect request: file is required")
+	}
+	fh := f.File["file"][0]
+	file, err := fh.Open()
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, ErrorCodeIncorrectRequest, "incorrect request: %s", err)
+	}
+	defer file.Close()
+	zr, err := zip.NewReader(file, fh.Size)
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, ErrorCodeIncorrectRequest, "incorrect request: file is not zip archive: %s", err)
+	}
+	var suites []junitSuite
+	for _, zf := range zr.File {
+		if path.Ext(zf.Name) != ".xml" {
+			continue
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			return nil, errorf(http.StatusBadRequest, ErrorCodeIncorrectRequest, "incorrect request: %s", err)
+		}
+		data, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, errorf(http.StatusBadRequest, ErrorCodeIncorrectRequest, "incorrect request: %s", err)
+		}
+		var root junitSuite
+		if err := xml.Unmarshal(data, &root); err != nil {
+			return nil, errorf(http.StatusBadRequest, ErrorCodeIncorrectRequest, "incorrect request: %s: %s", zf.Name, err)
+		}
+		if root.XMLName.Local == "testsuites" {
+			suites = append(suites, root.Suites...)
+		} else {
+			suites = append(suites, root)
+		}
+	}
+	now := time.Now()
+	id, uuid := s.newId()
+	l := &Launch{
+		Uuid:      uuid,
+		Id:        id,
+		Number:    len(s.launches) + 1,
+		Name:      strings.TrimSuffix(fh.Filename, path.Ext(fh.Filename)),
+		Mode:      "DEFAULT",
+		StartTime: now,
+		EndTime:   now,
+		Status:    "PASSED",
+	}
+	s.launches = append(s.launches, l)
+	for _, suite := range suites {
+		if s.importSuite(l, "", suite, now) == "FAILED" {
+			l.Status = "FAILED"
+		}
+	}
+	return map[string]interface{}{"message": fmt.Sprintf("Launch with id = %s is successfully imported.", l.Uuid)}, nil
+}
+
+// importSuite adds finished suite with nested suites and cases to launch, it returns status of the suite
+func (s *Server) importSuite(l *Launch, parentUuid string, suite junitSuite, t time.Time) string {
+	it := s.importItem(l, parentUuid, suite.Name, "SUITE", t)
+	it.Status = "PASSED"
+	for _, child := range suite.Suites {
+		if s.importSuite(l, it.Uuid, child, t) == "FAILED" {
+			it.Status = "FAILED"
+		}
+	}
+	for _, c := range suite.Cases {
+		test := s.importItem(l, it.Uuid, c.Name, "TEST", t)
+		test.Status = c.status()
+		if test.Status == "FAILED" {
+			it.Status = "FAILED"
+		}
+	}
+	return it.Status
+}
+
+func (s *Server) importItem(l *Launch, parentUuid string, name string, itemType string, t time.Time) *Item {
+	id, uuid := s.newId()
+	it := &Item{
+		Uuid:       uuid,
+		Id:         id,
+		LaunchUuid: l.Uuid,
+		ParentUuid: parentUuid,
+		Name:       name,
+		Type:       itemType,
+		StartTime:  t,
+		EndTime:    t,
+	}
+	s.items = append(s.items, it)
+	it.UniqueId = s.uniqueId(l, it)
+	return it
+}
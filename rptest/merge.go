@@ -0,0 +1,139 @@
+package rptest
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type mergeRQ struct {
+	Launches                []int       `json:"launches"`
+	Name                    string      `json:"name"`
+	Description             string      `json:"description"`
+	Attributes              []Attribute `json:"attributes"`
+	StartTime               rpTime      `json:"startTime"`
+	EndTime                 rpTime      `json:"endTime"`
+	Mode                    string      `json:"mode"`
+	MergeType               string      `json:"mergeType"`
+	ExtendSuitesDescription bool        `json:"extendSuitesDescription"`
+}
+
+// statusRanks orders launch statuses from the best to the worst, merged launch gets the worst one
+var statusRanks = map[string]int{"PASSED": 0, "SKIPPED": 1, "STOPPED": 2, "INTERRUPTED": 3, "FAILED": 4}
+
+// mergeLaunches moves items of finished launches into a new launch and removes the launches,
+// deep merge joins root items with the same name and type
+func (s *Server) mergeLaunches(body []byte) (interface{}, *apiError) {
+	var rq mergeRQ
+	if err := decode(body, &rq); err != nil {
+		return nil, err
+	}
+	if len(rq.Launches) == 0 || rq.Name == "" {
+		return nil, errorf(http.StatusBadRequest, ErrorCodeIncorrectRequest, "incorrect request: launches and name are required")
+	}
+	if rq.MergeType != "BASIC" && rq.MergeType != "DEEP" {
+		return nil, errorf(http.StatusBadRequest, ErrorCodeIncorrectRequest, "incorrect request: unknown merge type '%s'", rq.MergeType)
+	}
+	sources := make([]*Launch, 0, len(rq.Launches))
+	for _, id := range rq.Launches {
+		l := s.launch(fmt.Sprint(id))
+		if l == nil {
+			return nil, errorf(http.StatusNotFound, ErrorCodeLaunchNotFound, "launch '%d' not found", id)
+		}
+		if !l.Finished() {
+			return nil, errorf(http.StatusBadRequest, ErrorCodeIncorrectRequest, "launch '%d' is not finished", id)
+		}
+		sources = append(sources, l)
+	}
+	id, uuid := s.newId()
+	merged := &Launch{
+		Uuid:        uuid,
+		Id:          id,
+		Number:      len(s.launches) + 1,
+		Name:        rq.Name,
+		Description: rq.Description,
+		Mode:        rq.Mode,
+		Attributes:  rq.Attributes,
+		StartTime:   rq.StartTime.Time,
+		EndTime:     rq.EndTime.Time,
+		Status:      "PASSED",
+	}
+	if merged.Mode == "" {
+		merged.Mode = "DEFAULT"
+	}
+	roots := make(map[string]*Item)
+	for _, l := range sources {
+		if rq.StartTime.IsZero() && (merged.StartTime.IsZero() || l.StartTime.Before(merged.StartTime)) {
+			merged.StartTime = l.StartTime
+		}
+		if rq.EndTime.IsZero() && l.EndTime.After(merged.EndTime) {
+			merged.EndTime = l.EndTime
+		}
+		if statusRanks[l.Status] > statusRanks[merged.Status] {
+			merged.Status = l.Status
+		}
+		s.moveItems(l, merged, rq, roots)
+	}
+	if merged.EndTime.IsZero() {
+		merged.EndTime = time.Now()
+	}
+	launches := make([]*Launch, 0, len(s.launches))
+	for _, l := range s.launches {
+		if !containsLaunch(sources, l) {
+			launches = append(launches, l)
+		}
+	}
+	s.launches = append(launches, merged)
+	return s.launchResource(merged).json, nil
+}
+
+// moveItems moves items of launch l into merged, roots are root items already moved by name and type
+func (s *Server) moveItems(l *Launch, merged *Launch, rq mergeRQ, roots map[string]*Item) {
+	items := make([]*Item, 0, len(s.items))
+	for _, it := range s.items {
+		if it.LaunchUuid != l.Uuid {
+			items = append(items, it)
+			continue
+		}
+		it.LaunchUuid = merged.Uuid
+		if it.ParentUuid != "" {
+			items = append(items, it)
+			continue
+		}
+		if rq.ExtendSuitesDescription {
+			it.Description = fmt.Sprintf("%s\r\nMerged from launch '%s' #%d", it.Description, l.Name, l.Number)
+		}
+		key := it.Type + "/" + it.Name
+		root, ok := roots[key]
+		if rq.MergeType != "DEEP" || !ok {
+			roots[key] = it
+			items = append(items, it)
+			continue
+		}
+		// children are moved under the suite with the same name from the previous launches
+		for _, child := range s.items {
+			if child.ParentUuid == it.Uuid {
+				child.ParentUuid = root.Uuid
+			}
+		}
+		if it.StartTime.Before(root.StartTime) {
+			root.StartTime = it.StartTime
+		}
+		if it.EndTime.After(root.EndTime) {
+			root.EndTime = it.EndTime
+		}
+		if statusRanks[it.Status] > statusRanks[root.Status] {
+			root.Status = it.Status
+		}
+	}
+	s.items = items
+}
+
+func containsLaunch(launches []*Launch, l *Launch) bool {
+	for _, ll := range launches {
+		if ll == l {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,525 @@
+package rptest
+
+import (
+	"hash/fnv"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defectGroups maps defect type locator prefixes and v1 issue types to statistics groups
+var defectGroups = map[string]string{
+	"PB":             "product_bug",
+	"AB":             "automation_bug",
+	"SI":             "system_issue",
+	"TI":             "to_investigate",
+	"ND":             "no_defect",
+	"PRODUCT_BUG":    "product_bug",
+	"AUTOMATION_BUG": "automation_bug",
+	"SYSTEM_ISSUE":   "system_issue",
+	"TO_INVESTIGATE": "to_investigate",
+	"NOT_ISSUE":      "no_defect",
+	"NO_DEFECT":      "no_defect",
+}
+
+// v1IssueKeys are snake case issue keys of api v1 and their api v2 names
+var v1IssueKeys = map[string]string{
+	"issue_type":      "issueType",
+	"auto_analyzed":   "autoAnalyzed",
+	"ignore_analyzer": "ignoreAnalyzer",
+}
+
+// issueFlag returns analyzer flag of item issue by api v2 key or its api v1 name
+func issueFlag(it *Item, key string) bool {
+	for k, v2 := range v1IssueKeys {
+		if v2 == key {
+			if v, _ := it.Issue[k].(bool); v {
+				return true
+			}
+		}
+	}
+	v, _ := it.Issue[key].(bool)
+	return v
+}
+
+// issueType returns issue type of finished item, failed items without issue are to investigate
+func issueType(it *Item) string {
+	for _, k := range []string{"issueType", "issue_type"} {
+		if t, ok := it.Issue[k].(string); ok && t != "" {
+			return t
+		}
+	}
+	if it.Status == "FAILED" {
+		return "TI001"
+	}
+	return ""
+}
+
+func defectGroup(issueType string) string {
+	if g, ok := defectGroups[issueType]; ok {
+		return g
+	}
+	if len(issueType) >= 2 {
+		return defectGroups[issueType[:2]]
+	}
+	return ""
+}
+
+// statistics counts executions and defects of leaf items
+func (s *Server) statistics(items []*Item) map[string]interface{} {
+	executions := map[string]int{"total": 0, "passed": 0, "failed": 0, "skipped": 0}
+	defects := make(map[string]map[string]int)
+	for _, it := range items {
+		if !it.Finished() || s.hasChildren(it.Uuid) {
+			continue
+		}
+		executions["total"]++
+		switch it.Status {
+		case "PASSED":
+			executions["passed"]++
+		case "FAILED":
+			executions["failed"]++
+		case "SKIPPED":
+			executions["skipped"]++
+		}
+		t := issueType(it)
+		if g := defectGroup(t); g != "" {
+			if defects[g] == nil {
+				defects[g] = make(map[string]int)
+			}
+			defects[g]["total"]++
+			defects[g][t]++
+		}
+	}
+	return map[string]interface{}{"executions": executions, "defects": defects}
+}
+
+func (s *Server) hasChildren(uuid string) bool {
+	for _, it := range s.items {
+		if it.ParentUuid == uuid {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) launchItems(launchUuid string) []*Item {
+	var res []*Item
+	for _, it := range s.items {
+		if it.LaunchUuid == launchUuid {
+			res = append(res, it)
+		}
+	}
+	return res
+}
+
+func millis(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// attributes returns v2 attributes or v1 tags converted to attributes
+func attributes(attrs []Attribute, tags []string) []Attribute {
+	res := append([]Attribute(nil), attrs...)
+	for _, t := range tags {
+		kv := strings.SplitN(t, ":", 2)
+		if len(kv) == 2 {
+			res = append(res, Attribute{Key: kv[0], Value: kv[1]})
+			continue
+		}
+		res = append(res, Attribute{Value: t})
+	}
+	return res
+}
+
+func status(status string, finished bool) string {
+	if !finished {
+		return "IN_PROGRESS"
+	}
+	return status
+}
+
+// resource is a json resource with values used for filtering and sorting
+type resource struct {
+	json   map[string]interface{}
+	fields map[string][]string
+}
+
+func (s *Server) launchResource(l *Launch) resource {
+	attrs := attributes(l.Attributes, l.Tags)
+	r := resource{
+		json: map[string]interface{}{
+			"id":          l.Id,
+			"uuid":        l.Uuid,
+			"name":        l.Name,
+			"number":      l.Number,
+			"description": l.Description,
+			"owner":       "rptest",
+			"status":      status(l.Status, l.Finished()),
+			"mode":        l.Mode,
+			"startTime":   millis(l.StartTime),
+			"endTime":     millis(l.EndTime),
+			"attributes":  attrs,
+			"statistics":  s.statistics(s.launchItems(l.Uuid)),
+		},
+		fields: map[string][]string{
+			"id":          {strconv.Itoa(l.Id)},
+			"uuid":        {l.Uuid},
+			"name":        {l.Name},
+			"number":      {strconv.Itoa(l.Number)},
+			"description": {l.Description},
+			"status":      {status(l.Status, l.Finished())},
+			"mode":        {l.Mode},
+			"startTime":   {timeField(l.StartTime)},
+			"endTime":     {timeField(l.EndTime)},
+		},
+	}
+	addAttributeFields(r.fields, attrs)
+	return r
+}
+
+func timeField(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+}
+
+func addAttributeFields(fields map[string][]string, attrs []Attribute) {
+	for _, a := range attrs {
+		composite := a.Value
+		if a.Key != "" {
+			composite = a.Key + ":" + a.Value
+		}
+		fields["compositeAttribute"] = append(fields["compositeAttribute"], composite)
+		fields["attributeKey"] = append(fields["attributeKey"], a.Key)
+		fields["attributeValue"] = append(fields["attributeValue"], a.Value)
+	}
+}
+
+// condition is filter.<op>.<field>=value query parameter
+type condition struct {
+	op     string
+	field  string
+	values []string
+}
+
+func parseConditions(q url.Values) []condition {
+	var res []condition
+	for k, vs := range q {
+		parts := strings.SplitN(k, ".", 3)
+		if len(parts) != 3 || parts[0] != "filter" {
+			continue
+		}
+		for _, v := range vs {
+			res = append(res, condition{op: parts[1], field: parts[2], values: strings.Split(v, ",")})
+		}
+	}
+	return res
+}
+
+func (c condition) match(fields map[string][]string) bool {
+	got := fields[c.field]
+	switch c.op {
+	case "eq":
+		return contains(got, c.values[0])
+	case "ne":
+		return !contains(got, c.values[0])
+	case "cnt":
+		for _, g := range got {
+			if strings.Contains(strings.ToLower(g), strings.ToLower(c.values[0])) {
+				return true
+			}
+		}
+		return false
+	case "has":
+		for _, v := range c.values {
+			if !contains(got, v) {
+				return false
+			}
+		}
+		return true
+	case "in":
+		for _, v := range c.values {
+			if contains(got, v) {
+				return true
+			}
+		}
+		return false
+	case "gte", "lte", "gt", "lt":
+		want, err := strconv.ParseFloat(c.values[0], 64)
+		if err != nil || len(got) == 0 {
+			return false
+		}
+		g, err := strconv.ParseFloat(got[0], 64)
+		if err != nil {
+			return false
+		}
+		switch c.op {
+		case "gte":
+			return g >= want
+		case "lte":
+			return g <= want
+		case "gt":
+			return g > want
+		}
+		return g < want
+	}
+	return false
+}
+
+func contains(values []string, v string) bool {
+	for _, g := range values {
+		if g == v {
+			return true
+		}
+	}
+	return false
+}
+
+// page filters, sorts and pages resources by query
+func page(q url.Values, all []resource) map[string]interface{} {
+	conds := parseConditions(q)
+	var matched []resource
+	for _, r := range all {
+		ok := true
+		for _, c := range conds {
+			if !c.match(r.fields) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			matched = append(matched, r)
+		}
+	}
+	for i := len(q["page.sort"]) - 1; i >= 0; i-- {
+		parts := strings.Split(q["page.sort"][i], ",")
+		field, desc := parts[0], len(parts) > 1 && strings.EqualFold(parts[1], "DESC")
+		sort.SliceStable(matched, func(a, b int) bool {
+			if desc {
+				return less(matched[b].fields[field], matched[a].fields[field])
+			}
+			return less(matched[a].fields[field], matched[b].fields[field])
+		})
+	}
+	number, size := 1, 20
+	if n, err := strconv.Atoi(q.Get("page.page")); err == nil && n > 0 {
+		number = n
+	}
+	if n, err := strconv.Atoi(q.Get("page.size")); err == nil && n > 0 {
+		size = n
+	}
+	content := make([]map[string]interface{}, 0, size)
+	for i := (number - 1) * size; i < len(matched) && i < number*size; i++ {
+		content = append(content, matched[i].json)
+	}
+	return map[string]interface{}{
+		"content": content,
+		"page": map[string]int{
+			"number":        number,
+			"size":          size,
+			"totalElements": len(matched),
+			"totalPages":    (len(matched) + size - 1) / size,
+		},
+	}
+}
+
+// less compares first values numerically if both are numbers
+func less(a []string, b []string) bool {
+	var x, y string
+	if len(a) > 0 {
+		x = a[0]
+	}
+	if len(b) > 0 {
+		y = b[0]
+	}
+	fx, errx := strconv.ParseFloat(x, 64)
+	fy, erry := strconv.ParseFloat(y, 64)
+	if errx == nil && erry == nil {
+		return fx < fy
+	}
+	return x < y
+}
+
+func (s *Server) listLaunches(q url.Values) (interface{}, *apiError) {
+	all := make([]resource, 0, len(s.launches))
+	for _, l := range s.launches {
+		all = append(all, s.launchResource(l))
+	}
+	return page(q, all), nil
+}
+
+func (s *Server) getLaunch(id string) (interface{}, *apiError) {
+	l := s.launch(id)
+	if l == nil {
+		return nil, errorf(http.StatusNotFound, ErrorCodeLaunchNotFound, "launch '%s' not found", id)
+	}
+	return s.launchResource(l).json, nil
+}
+
+// itemPath returns ancestors of the item from the root and the item itself
+func (s *Server) itemPath(it *Item) []*Item {
+	path := []*Item{it}
+	for p := it; p.ParentUuid != ""; {
+		if p = s.item(p.ParentUuid); p == nil {
+			break
+		}
+		path = append([]*Item{p}, path...)
+	}
+	return path
+}
+
+func (s *Server) itemResource(it *Item) resource {
+	l := s.launch(it.LaunchUuid)
+	path := s.itemPath(it)
+	ids := make([]string, 0, len(path))
+	itemPaths := make([]map[string]interface{}, 0, len(path)-1)
+	for _, p := range path {
+		ids = append(ids, strconv.Itoa(p.Id))
+		if p != it {
+			itemPaths = append(itemPaths, map[string]interface{}{"id": p.Id, "name": p.Name})
+		}
+	}
+	var parent int
+	if len(path) > 1 {
+		parent = path[len(path)-2].Id
+	}
+	hasChildren := s.hasChildren(it.Uuid)
+	attrs := attributes(it.Attributes, it.Tags)
+	var issue map[string]interface{}
+	if t := issueType(it); t != "" && it.Finished() {
+		issue = map[string]interface{}{"issueType": t, "autoAnalyzed": false, "ignoreAnalyzer": false}
+		for k, v := range it.Issue {
+			if v2, ok := v1IssueKeys[k]; ok {
+				k = v2
+			}
+			issue[k] = v
+		}
+		issue["issueType"] = t
+		tickets := make([]Ticket, len(it.Tickets))
+		copy(tickets, it.Tickets)
+		issue["externalSystemIssues"] = tickets
+	}
+	r := resource{
+		json: map[string]interface{}{
+			"id":          it.Id,
+			"uuid":        it.Uuid,
+			"name":        it.Name,
+			"description": it.Description,
+			"type":        it.Type,
+			"status":      status(it.Status, it.Finished()),
+			"startTime":   millis(it.StartTime),
+			"endTime":     millis(it.EndTime),
+			"launchId":    l.Id,
+			"parent":      parent,
+			"path":        strings.Join(ids, "."),
+			"pathNames": map[string]interface{}{
+				"launchPathName": map[string]interface{}{"name": l.Name, "number": l.Number},
+				"itemPaths":      itemPaths,
+			},
+			"uniqueId":    it.UniqueId,
+			"hasChildren": hasChildren,
+			"hasStats":    true,
+			"attributes":  attrs,
+			"parameters":  it.Parameters,
+			"issue":       issue,
+			"statistics":  s.statistics(s.subtree(it)),
+		},
+		fields: map[string][]string{
+			"id":          {strconv.Itoa(it.Id)},
+			"uuid":        {it.Uuid},
+			"name":        {it.Name},
+			"type":        {it.Type},
+			"status":      {status(it.Status, it.Finished())},
+			"launchId":    {strconv.Itoa(l.Id)},
+			"parentId":    {strconv.Itoa(parent)},
+			"uniqueId":    {it.UniqueId},
+			"hasChildren": {strconv.FormatBool(hasChildren)},
+			"issueType":   {issueType(it)},
+			"startTime":   {timeField(it.StartTime)},
+			"endTime":     {timeField(it.EndTime)},
+		},
+	}
+	addAttributeFields(r.fields, attrs)
+	return r
+}
+
+// subtree returns the item and all its descendants
+func (s *Server) subtree(root *Item) []*Item {
+	res := []*Item{root}
+	for i := 0; i < len(res); i++ {
+		for _, it := range s.items {
+			if it.ParentUuid == res[i].Uuid {
+				res = append(res, it)
+			}
+		}
+	}
+	return res
+}
+
+func (s *Server) listItems(q url.Values) (interface{}, *apiError) {
+	all := make([]resource, 0, len(s.items))
+	for _, it := range s.items {
+		all = append(all, s.itemResource(it))
+	}
+	return page(q, all), nil
+}
+
+func (s *Server) getItem(id string) (interface{}, *apiError) {
+	it := s.item(id)
+	if it == nil {
+		return nil, errorf(http.StatusNotFound, ErrorCodeItemNotFound, "test item '%s' not found", id)
+	}
+	return s.itemResource(it).json, nil
+}
+
+// itemHistory returns runs of items with the same unique id in historyDepth last launches with the same name,
+// from the launch of the item backwards
+func (s *Server) itemHistory(q url.Values) (interface{}, *apiError) {
+	depth := 5
+	if n, err := strconv.Atoi(q.Get("historyDepth")); err == nil && n > 0 {
+		depth = n
+	}
+	var ids []string
+	for _, k := range []string{"filter.in.id", "filter.eq.id"} {
+		for _, v := range q[k] {
+			ids = append(ids, strings.Split(v, ",")...)
+		}
+	}
+	content := make([]map[string]interface{}, 0, len(ids))
+	for _, id := range ids {
+		it := s.item(id)
+		if it == nil {
+			return nil, errorf(http.StatusNotFound, ErrorCodeItemNotFound, "test item '%s' not found", id)
+		}
+		base := s.launch(it.LaunchUuid)
+		var launches []*Launch
+		for _, l := range s.launches {
+			if l.Name == base.Name && l.Number <= base.Number {
+				launches = append(launches, l)
+			}
+		}
+		sort.Slice(launches, func(a, b int) bool { return launches[a].Number > launches[b].Number })
+		if len(launches) > depth {
+			launches = launches[:depth]
+		}
+		resources := make([]map[string]interface{}, 0, len(launches))
+		for _, l := range launches {
+			for _, run := range s.items {
+				if run.LaunchUuid == l.Uuid && run.UniqueId == it.UniqueId {
+					resources = append(resources, s.itemResource(run).json)
+				}
+			}
+		}
+		h := fnv.New32a()
+		h.Write([]byte(it.UniqueId))
+		content = append(content, map[string]interface{}{"testCaseHash": int32(h.Sum32()), "resources": resources})
+	}
+	return map[string]interface{}{"content": content}, nil
+}
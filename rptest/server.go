@@ -0,0 +1,760 @@
+// Package rptest provides in-process fake Report Portal server for testing reporters offline.
+// Server implements launch, item, log and issue endpoints of v1 and v2 api for any project,
+// launch and item queries with filters, sorting and pagination, item history,
+// keeps launches, item tree and logs in memory and can inject failures and latency
+//
+//	s := rptest.NewServer()
+//	defer s.Close()
+//	c := rpgoclient.New(s.URL, "project", "token", "", false)
+//	...
+//	item, _ := s.ItemByName("test")
+//	assert.Equal(t, "PASSED", item.Status)
+package rptest
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Report Portal error codes returned by the server
+const (
+	ErrorCodeIncorrectRequest    = 4001
+	ErrorCodeLaunchNotFound      = 4041
+	ErrorCodeItemNotFound        = 40422
+	ErrorCodeFinishNotAllowed    = 40018
+	ErrorCodeInjectedFailure     = 5000
+	ErrorCodeUnsupportedEndpoint = 4040
+)
+
+type Attribute struct {
+	Key    string `json:"key,omitempty"`
+	Value  string `json:"value"`
+	System bool   `json:"system,omitempty"`
+}
+
+type Parameter struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Ticket is bts ticket linked to item
+type Ticket struct {
+	BtsProject string `json:"btsProject"`
+	BtsUrl     string `json:"btsUrl"`
+	SubmitDate int64  `json:"submitDate"`
+	TicketId   string `json:"ticketId"`
+	Url        string `json:"url"`
+}
+
+type Launch struct {
+	// Uuid is id returned on start, generated by client in v2 api
+	Uuid        string
+	Id          int
+	Number      int
+	Name        string
+	Description string
+	Mode        string
+	Tags        []string
+	Attributes  []Attribute
+	StartTime   time.Time
+	EndTime     time.Time
+	Status      string
+}
+
+// Finished reports whether launch is finished
+func (l Launch) Finished() bool {
+	return !l.EndTime.IsZero()
+}
+
+type Item struct {
+	// Uuid is id returned on start, generated by client in v2 api
+	Uuid        string
+	Id          int
+	LaunchUuid  string
+	ParentUuid  string
+	UniqueId    string
+	Name        string
+	Type        string
+	Description string
+	Tags        []string
+	Attributes  []Attribute
+	Parameters  []Parameter
+	StartTime   time.Time
+	EndTime     time.Time
+	Status      string
+	Issue       map[string]interface{}
+	Tickets     []Ticket
+}
+
+// Finished reports whether item is finished
+func (i Item) Finished() bool {
+	return !i.EndTime.IsZero()
+}
+
+type Log struct {
+	Uuid     string
+	ItemUuid string
+	Time     time.Time
+	Message  string
+	Level    string
+	File     *File
+}
+
+// File is attachment of log
+type File struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Request is a request received by the server
+type Request struct {
+	Method string
+	Path   string
+	Body   []byte
+}
+
+// fault is injected failure, times < 0 fails all matching requests
+type fault struct {
+	method   string
+	pathPart string
+	status   int
+	times    int
+}
+
+// Server is fake Report Portal server, safe for concurrent use
+type Server struct {
+	URL string
+
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	latency  time.Duration
+	faults   []*fault
+	requests []Request
+	nextId   int
+	launches []*Launch
+	items    []*Item
+	logs     []*Log
+	// defectTypes are defect types of project settings
+	defectTypes []DefectType
+}
+
+// NewServer starts fake server, it must be closed with Close
+func NewServer() *Server {
+	s := &Server{defectTypes: defaultDefectTypes()}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.srv.URL
+	return s
+}
+
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// SetLatency delays every response by d
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// Fail makes next times requests with method, any if empty, and path containing pathPart fail with status,
+// times < 0 fails all matching requests
+func (s *Server) Fail(method string, pathPart string, status int, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &fault{method: method, pathPart: pathPart, status: status, times: times})
+}
+
+// Reset removes injected failures and latency
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+	s.latency = 0
+}
+
+// Requests returns received requests
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Launches returns launches in start order
+func (s *Server) Launches() []Launch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := make([]Launch, 0, len(s.launches))
+	for _, l := range s.launches {
+		res = append(res, *l)
+	}
+	return res
+}
+
+// Launch returns launch by uuid
+func (s *Server) Launch(uuid string) (Launch, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l := s.launch(uuid); l != nil {
+		return *l, true
+	}
+	return Launch{}, false
+}
+
+// Items returns items in start order
+func (s *Server) Items() []Item {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := make([]Item, 0, len(s.items))
+	for _, it := range s.items {
+		res = append(res, *it)
+	}
+	return res
+}
+
+// Item returns item by uuid or numeric id
+func (s *Server) Item(id string) (Item, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if it := s.item(id); it != nil {
+		return *it, true
+	}
+	return Item{}, false
+}
+
+// ItemByName returns the first started item with name
+func (s *Server) ItemByName(name string) (Item, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, it := range s.items {
+		if it.Name == name {
+			return *it, true
+		}
+	}
+	return Item{}, false
+}
+
+// Children returns child items of item or root items of launch by uuid, in start order
+func (s *Server) Children(uuid string) []Item {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res []Item
+	for _, it := range s.items {
+		if it.ParentUuid == uuid || (it.ParentUuid == "" && it.LaunchUuid == uuid) {
+			res = append(res, *it)
+		}
+	}
+	return res
+}
+
+// Logs returns logs of item by uuid in receive order
+func (s *Server) Logs(itemUuid string) []Log {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res []Log
+	for _, l := range s.logs {
+		if l.ItemUuid == itemUuid {
+			res = append(res, *l)
+		}
+	}
+	return res
+}
+
+func (s *Server) launch(uuid string) *Launch {
+	for _, l := range s.launches {
+		if l.Uuid == uuid || strconv.Itoa(l.Id) == uuid {
+			return l
+		}
+	}
+	return nil
+}
+
+func (s *Server) item(id string) *Item {
+	for _, it := range s.items {
+		if it.Uuid == id || strconv.Itoa(it.Id) == id {
+			return it
+		}
+	}
+	return nil
+}
+
+// apiError is Report Portal error response
+type apiError struct {
+	status    int
+	ErrorCode int    `json:"errorCode"`
+	Message   string `json:"message"`
+}
+
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+func errorf(status int, code int, format string, args ...interface{}) *apiError {
+	return &apiError{status: status, ErrorCode: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, Body: body})
+	latency := s.latency
+	f := s.fault(r)
+	s.mu.Unlock()
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if f != nil {
+		writeJSON(w, f.status, errorf(f.status, ErrorCodeInjectedFailure, "injected failure"))
+		return
+	}
+	resp, err := s.route(r, body)
+	if err != nil {
+		writeJSON(w, err.status, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// fault returns injected failure matching request, must be called with s.mu held
+func (s *Server) fault(r *http.Request) *fault {
+	for i, f := range s.faults {
+		if (f.method != "" && f.method != r.Method) || !strings.Contains(r.URL.Path, f.pathPart) {
+			continue
+		}
+		if f.times > 0 {
+			f.times--
+			if f.times == 0 {
+				s.faults = append(s.faults[:i:i], s.faults[i+1:]...)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// route dispatches /api/{version}/{project}/... requests
+func (s *Server) route(r *http.Request, body []byte) (interface{}, *apiError) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 4 || parts[0] != "api" {
+		return nil, errorf(http.StatusNotFound, ErrorCodeUnsupportedEndpoint, "unsupported endpoint %s %s", r.Method, r.URL.Path)
+	}
+	path := parts[3:]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case r.Method == "POST" && match(path, "launch"):
+		return s.startLaunch(body)
+	case r.Method == "POST" && match(path, "launch", "merge"):
+		return s.mergeLaunches(body)
+	case r.Method == "GET" && match(path, "launch"):
+		return s.listLaunches(r.URL.Query())
+	case r.Method == "GET" && match(path, "launch", "uuid", "*"):
+		return s.getLaunch(path[2])
+	case r.Method == "GET" && match(path, "launch", "*"):
+		return s.getLaunch(path[1])
+	case r.Method == "PUT" && match(path, "launch", "*", "finish"):
+		return s.finishLaunch(path[1], body)
+	case r.Method == "PUT" && match(path, "launch", "*", "stop"):
+		return s.stopLaunch(path[1], body)
+	case r.Method == "PUT" && match(path, "launch", "*", "update"):
+		return s.updateLaunch(path[1], body)
+	case r.Method == "DELETE" && match(path, "launch"):
+		return s.deleteLaunches(r.URL.Query())
+	case r.Method == "POST" && match(path, "launch", "import"):
+		return s.importLaunch(r, body)
+	case r.Method == "POST" && match(path, "launch", "analyze"):
+		return s.analyzeLaunch(body)
+	case r.Method == "GET" && match(path, "settings"):
+		return s.settings()
+	case r.Method == "PUT" && match(path, "item"):
+		return s.defineIssues(body)
+	case r.Method == "PUT" && match(path, "item", "issue", "link"):
+		return s.linkIssue(body)
+	case r.Method == "PUT" && match(path, "item", "issue", "unlink"):
+		return s.unlinkIssues(body)
+	case r.Method == "POST" && match(path, "item"):
+		return s.startItem("", body)
+	case r.Method == "POST" && match(path, "item", "*"):
+		return s.startItem(path[1], body)
+	case r.Method == "PUT" && match(path, "item", "*"):
+		return s.finishItem(path[1], body)
+	case r.Method == "GET" && match(path, "item"):
+		return s.listItems(r.URL.Query())
+	case r.Method == "GET" && match(path, "item", "history"):
+		return s.itemHistory(r.URL.Query())
+	case r.Method == "GET" && match(path, "item", "uuid", "*"):
+		return s.getItem(path[2])
+	case r.Method == "GET" && match(path, "item", "*"):
+		return s.getItem(path[1])
+	case r.Method == "POST" && match(path, "log"):
+		return s.log(r, body)
+	}
+	return nil, errorf(http.StatusNotFound, ErrorCodeUnsupportedEndpoint, "unsupported endpoint %s %s", r.Method, r.URL.Path)
+}
+
+// match reports whether path matches pattern, "*" matches any segment
+func match(path []string, pattern ...string) bool {
+	if len(path) != len(pattern) {
+		return false
+	}
+	for i, p := range pattern {
+		if p != "*" && p != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// rpTime is v1 RFC3339 time or v2 epoch millis
+type rpTime struct {
+	time.Time
+}
+
+func (t *rpTime) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		if s == "" {
+			return nil
+		}
+		tt, err := time.Parse(time.RFC3339, s)
+		t.Time = tt
+		return err
+	}
+	var ms int64
+	if err := json.Unmarshal(b, &ms); err != nil {
+		return err
+	}
+	if ms != 0 {
+		t.Time = time.Unix(0, ms*int64(time.Millisecond))
+	}
+	return nil
+}
+
+// firstTime returns v1 or v2 time, whichever is set
+func firstTime(ts ...rpTime) time.Time {
+	for _, t := range ts {
+		if !t.IsZero() {
+			return t.Time
+		}
+	}
+	return time.Time{}
+}
+
+// startRQ is start launch or item request of v1 or v2 api
+type startRQ struct {
+	Uuid        string      `json:"uuid"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Tags        []string    `json:"tags"`
+	Attributes  []Attribute `json:"attributes"`
+	StartTime   rpTime      `json:"start_time"`
+	StartTimeV2 rpTime      `json:"startTime"`
+	Mode        string      `json:"mode"`
+	Type        string      `json:"type"`
+	LaunchId    string      `json:"launch_id"`
+	LaunchUuid  string      `json:"launchUuid"`
+	Parameters  []Parameter `json:"parameters"`
+}
+
+type finishRQ struct {
+	Status    string                 `json:"status"`
+	EndTime   rpTime                 `json:"end_time"`
+	EndTimeV2 rpTime                 `json:"endTime"`
+	Issue     map[string]interface{} `json:"issue"`
+}
+
+type logRQ struct {
+	ItemId   string `json:"item_id"`
+	ItemUuid string `json:"itemUuid"`
+	Time     rpTime `json:"time"`
+	Message  string `json:"message"`
+	Level    string `json:"level"`
+	File     *struct {
+		Name string `json:"name"`
+	} `json:"file"`
+}
+
+func decode(body []byte, v interface{}) *apiError {
+	if err := json.Unmarshal(body, v); err != nil {
+		return errorf(http.StatusBadRequest, ErrorCodeIncorrectRequest, "incorrect request: %s", err)
+	}
+	return nil
+}
+
+func (s *Server) newId() (int, string) {
+	s.nextId++
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return s.nextId, fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func (s *Server) startLaunch(body []byte) (interface{}, *apiError) {
+	var rq startRQ
+	if err := decode(body, &rq); err != nil {
+		return nil, err
+	}
+	id, uuid := s.newId()
+	if rq.Uuid != "" {
+		uuid = rq.Uuid
+	}
+	l := &Launch{
+		Uuid:        uuid,
+		Id:          id,
+		Number:      len(s.launches) + 1,
+		Name:        rq.Name,
+		Description: rq.Description,
+		Mode:        rq.Mode,
+		Tags:        rq.Tags,
+		Attributes:  rq.Attributes,
+		StartTime:   firstTime(rq.StartTime, rq.StartTimeV2),
+	}
+	s.launches = append(s.launches, l)
+	return map[string]interface{}{"id": l.Uuid, "number": l.Number}, nil
+}
+
+func (s *Server) finishLaunch(uuid string, body []byte) (interface{}, *apiError) {
+	var rq finishRQ
+	if err := decode(body, &rq); err != nil {
+		return nil, err
+	}
+	l := s.launch(uuid)
+	if l == nil {
+		return nil, errorf(http.StatusNotFound, ErrorCodeLaunchNotFound, "launch '%s' not found", uuid)
+	}
+	if l.Finished() {
+		return nil, errorf(http.StatusNotAcceptable, ErrorCodeFinishNotAllowed, "launch '%s' is already finished", uuid)
+	}
+	l.EndTime = firstTime(rq.EndTime, rq.EndTimeV2)
+	if l.EndTime.IsZero() {
+		l.EndTime = time.Now()
+	}
+	l.Status = rq.Status
+	return map[string]interface{}{"id": l.Uuid, "number": l.Number, "link": fmt.Sprintf("%s/ui/#launches/all/%d", s.URL, l.Id)}, nil
+}
+
+func (s *Server) startItem(parentUuid string, body []byte) (interface{}, *apiError) {
+	var rq startRQ
+	if err := decode(body, &rq); err != nil {
+		return nil, err
+	}
+	launchUuid := rq.LaunchId
+	if rq.LaunchUuid != "" {
+		launchUuid = rq.LaunchUuid
+	}
+	l := s.launch(launchUuid)
+	if l == nil {
+		return nil, errorf(http.StatusNotFound, ErrorCodeLaunchNotFound, "launch '%s' not found", launchUuid)
+	}
+	if parentUuid != "" {
+		parent := s.item(parentUuid)
+		if parent == nil {
+			return nil, errorf(http.StatusNotFound, ErrorCodeItemNotFound, "test item '%s' not found", parentUuid)
+		}
+		parentUuid = parent.Uuid
+	}
+	id, uuid := s.newId()
+	if rq.Uuid != "" {
+		uuid = rq.Uuid
+	}
+	it := &Item{
+		Uuid:        uuid,
+		Id:          id,
+		LaunchUuid:  l.Uuid,
+		ParentUuid:  parentUuid,
+		Name:        rq.Name,
+		Type:        rq.Type,
+		Description: rq.Description,
+		Tags:        rq.Tags,
+		Attributes:  rq.Attributes,
+		Parameters:  rq.Parameters,
+		StartTime:   firstTime(rq.StartTime, rq.StartTimeV2),
+	}
+	it.UniqueId = s.uniqueId(l, it)
+	s.items = append(s.items, it)
+	return map[string]interface{}{"id": it.Uuid, "uniqueId": it.UniqueId}, nil
+}
+
+// uniqueId identifies the same test across launches by launch name, item path and parameters
+func (s *Server) uniqueId(l *Launch, it *Item) string {
+	h := md5.New()
+	io.WriteString(h, l.Name)
+	for _, p := range s.itemPath(it) {
+		io.WriteString(h, ";"+p.Name)
+	}
+	for _, p := range it.Parameters {
+		io.WriteString(h, ";"+p.Key+"="+p.Value)
+	}
+	return fmt.Sprintf("auto:%x", h.Sum(nil))
+}
+
+func (s *Server) finishItem(uuid string, body []byte) (interface{}, *apiError) {
+	var rq finishRQ
+	if err := decode(body, &rq); err != nil {
+		return nil, err
+	}
+	it := s.item(uuid)
+	if it == nil {
+		return nil, errorf(http.StatusNotFound, ErrorCodeItemNotFound, "test item '%s' not found", uuid)
+	}
+	if it.Finished() {
+		return nil, errorf(http.StatusNotAcceptable, ErrorCodeFinishNotAllowed, "test item '%s' is already finished", uuid)
+	}
+	it.EndTime = firstTime(rq.EndTime, rq.EndTimeV2)
+	if it.EndTime.IsZero() {
+		it.EndTime = time.Now()
+	}
+	it.Status = rq.Status
+	it.Issue = rq.Issue
+	msg := fmt.Sprintf("TestItem with ID = '%s' successfully finished.", it.Uuid)
+	return map[string]interface{}{"msg": msg, "message": msg}, nil
+}
+
+func (s *Server) linkIssue(body []byte) (interface{}, *apiError) {
+	var rq struct {
+		Issues      []Ticket `json:"issues"`
+		TestItemIds []int    `json:"testItemIds"`
+	}
+	if err := decode(body, &rq); err != nil {
+		return nil, err
+	}
+	for _, id := range rq.TestItemIds {
+		if s.item(strconv.Itoa(id)) == nil {
+			return nil, errorf(http.StatusNotFound, ErrorCodeItemNotFound, "test item '%d' not found", id)
+		}
+	}
+	for _, id := range rq.TestItemIds {
+		it := s.item(strconv.Itoa(id))
+		it.Tickets = append(it.Tickets, rq.Issues...)
+	}
+	return map[string]interface{}{"msg": fmt.Sprintf("%d tickets linked to %d items", len(rq.Issues), len(rq.TestItemIds))}, nil
+}
+
+func (s *Server) unlinkIssues(body []byte) (interface{}, *apiError) {
+	var rq struct {
+		TestItemIds []int    `json:"testItemIds"`
+		TicketIds   []string `json:"ticketIds"`
+	}
+	if err := decode(body, &rq); err != nil {
+		return nil, err
+	}
+	for _, id := range rq.TestItemIds {
+		if s.item(strconv.Itoa(id)) == nil {
+			return nil, errorf(http.StatusNotFound, ErrorCodeItemNotFound, "test item '%d' not found", id)
+		}
+	}
+	var unlinked int
+	for _, id := range rq.TestItemIds {
+		it := s.item(strconv.Itoa(id))
+		var tickets []Ticket
+		for _, t := range it.Tickets {
+			if contains(rq.TicketIds, t.TicketId) {
+				unlinked++
+				continue
+			}
+			tickets = append(tickets, t)
+		}
+		it.Tickets = tickets
+	}
+	return map[string]interface{}{"msg": fmt.Sprintf("%d tickets unlinked from %d items", unlinked, len(rq.TestItemIds))}, nil
+}
+
+func (s *Server) log(r *http.Request, body []byte) (interface{}, *apiError) {
+	mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		var rq logRQ
+		if err := decode(body, &rq); err != nil {
+			return nil, err
+		}
+		l, err := s.addLog(rq, nil)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"id": l.Uuid}, nil
+	}
+	var rqs []logRQ
+	files := make(map[string]*File)
+	mr := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errorf(http.StatusBadRequest, ErrorCodeIncorrectRequest, "incorrect multipart request: %s", err)
+		}
+		data, err := ioutil.ReadAll(p)
+		if err != nil {
+			return nil, errorf(http.StatusBadRequest, ErrorCodeIncorrectRequest, "incorrect multipart request: %s", err)
+		}
+		if p.FormName() == "json_request_part" {
+			if err := decode(data, &rqs); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		files[p.FileName()] = &File{Name: p.FileName(), ContentType: p.Header.Get("Content-Type"), Data: data}
+	}
+	responses := make([]map[string]interface{}, 0, len(rqs))
+	for _, rq := range rqs {
+		var f *File
+		if rq.File != nil {
+			f = files[rq.File.Name]
+		}
+		l, err := s.addLog(rq, f)
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, map[string]interface{}{"id": l.Uuid})
+	}
+	return map[string]interface{}{"responses": responses}, nil
+}
+
+func (s *Server) addLog(rq logRQ, f *File) (*Log, *apiError) {
+	itemUuid := rq.ItemId
+	if rq.ItemUuid != "" {
+		itemUuid = rq.ItemUuid
+	}
+	it := s.item(itemUuid)
+	if it == nil {
+		return nil, errorf(http.StatusNotFound, ErrorCodeItemNotFound, "test item '%s' not found", itemUuid)
+	}
+	_, uuid := s.newId()
+	l := &Log{
+		Uuid:     uuid,
+		ItemUuid: it.Uuid,
+		Time:     rq.Time.Time,
+		Message:  rq.Message,
+		Level:    rq.Level,
+		File:     f,
+	}
+	s.logs = append(s.logs, l)
+	return l, nil
+}
@@ -0,0 +1,112 @@
+package rptest_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skudasov/rpgoclient"
+	"github.com/skudasov/rpgoclient/rptest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_ItemTree(t *testing.T) {
+	for _, version := range []int{1, 2} {
+		t.Run(strconv.Itoa(version), func(t *testing.T) {
+			s := rptest.NewServer()
+			defer s.Close()
+			c := rpgoclient.New(s.URL, "proj", "token", "http://jira", false, rpgoclient.WithAPIVersion(version), rpgoclient.WithVerbosity("error"))
+			launch, err := c.StartLaunch("run", "desc", "2021-03-01T10:00:00Z", []string{"env:prod"}, "DEFAULT")
+			assert.NoError(t, err)
+			suite, err := c.StartTestItem("suite", "SUITE", "", "", nil, nil)
+			assert.NoError(t, err)
+			test, err := c.StartTestItem("test", "TEST", "", "", nil, []map[string]string{{"key": "k", "value": "v"}})
+			assert.NoError(t, err)
+			_, err = c.Log("message", "INFO")
+			assert.NoError(t, err)
+			_, err = c.LogWithAttachment(test.Id, "screenshot", "ERROR", "page.html", "text/html", strings.NewReader("<html/>"))
+			assert.NoError(t, err)
+			_, err = c.FinishTestItem("FAILED", "", nil)
+			assert.NoError(t, err)
+			_, err = c.FinishTestItem("FAILED", "", nil)
+			assert.NoError(t, err)
+			item, err := c.GetItemIdByUUID(test.Id)
+			assert.NoError(t, err)
+			_, err = c.LinkIssue(item.Id, "PRJ-1", "http://jira/browse/PRJ-1")
+			assert.NoError(t, err)
+			_, err = c.FinishLaunch("FAILED", "")
+			assert.NoError(t, err)
+
+			l, ok := s.Launch(launch.Id)
+			if assert.True(t, ok) {
+				assert.Equal(t, "run", l.Name)
+				assert.Equal(t, "FAILED", l.Status)
+				assert.True(t, l.Finished())
+				assert.Equal(t, time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC), l.StartTime.UTC())
+			}
+			roots := s.Children(launch.Id)
+			if assert.Len(t, roots, 1) {
+				assert.Equal(t, suite.Id, roots[0].Uuid)
+			}
+			children := s.Children(suite.Id)
+			if assert.Len(t, children, 1) {
+				assert.Equal(t, "test", children[0].Name)
+				assert.Equal(t, "FAILED", children[0].Status)
+				assert.Equal(t, []rptest.Parameter{{Key: "k", Value: "v"}}, children[0].Parameters)
+				assert.Equal(t, "PRJ-1", children[0].Tickets[0].TicketId)
+			}
+			logs := s.Logs(test.Id)
+			if assert.Len(t, logs, 2) {
+				assert.Equal(t, "message", logs[0].Message)
+				assert.Equal(t, "<html/>", string(logs[1].File.Data))
+			}
+		})
+	}
+}
+
+func TestServer_Validates(t *testing.T) {
+	s := rptest.NewServer()
+	defer s.Close()
+	c := rpgoclient.New(s.URL, "proj", "token", "", false, rpgoclient.WithVerbosity("fatal"))
+	_, err := c.FinishTestItemId("unknown", "PASSED", "", nil)
+	var apiErr *rpgoclient.APIError
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, rptest.ErrorCodeItemNotFound, apiErr.ErrorCode)
+	}
+	_, err = c.StartLaunch("run", "", "", nil, "DEFAULT")
+	assert.NoError(t, err)
+	_, err = c.FinishLaunch("PASSED", "")
+	assert.NoError(t, err)
+	_, err = c.FinishLaunchContext(context.Background(), "PASSED", "")
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, rptest.ErrorCodeFinishNotAllowed, apiErr.ErrorCode)
+	}
+}
+
+func TestServer_FailureAndLatency(t *testing.T) {
+	s := rptest.NewServer()
+	defer s.Close()
+	c := rpgoclient.New(s.URL, "proj", "token", "", false, rpgoclient.WithVerbosity("fatal"), rpgoclient.WithRetryPolicy(rpgoclient.RetryPolicy{
+		MaxRetries:      2,
+		RetryableStatus: func(code int) bool { return code >= 500 },
+	}))
+	s.Fail("POST", "/launch", 503, 2)
+	_, err := c.StartLaunch("run", "", "", nil, "DEFAULT")
+	assert.NoError(t, err)
+	assert.Len(t, s.Requests(), 3)
+	assert.Len(t, s.Launches(), 1)
+
+	s.Fail("", "/item", 503, -1)
+	_, err = c.StartTestItem("suite", "SUITE", "", "", nil, nil)
+	assert.True(t, errors.Is(err, rpgoclient.ErrHTTPRetriesReached))
+	s.Reset()
+
+	s.SetLatency(200 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = c.StartTestItemContext(ctx, "suite", "SUITE", "", "", nil, nil)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
@@ -0,0 +1,170 @@
+// Package rptesting mirrors go test lifecycle into Report Portal: every test and subtest run through
+// Run becomes an item which is finished when the test completes, test logs are attached to the item
+//
+//	func TestMain(m *testing.M) {
+//		c := rpgoclient.New(url, project, token, "", false)
+//		os.Exit(rptesting.Main(m, c, "unit tests", "", nil))
+//	}
+//
+//	func TestSum(t *testing.T) {
+//		rptesting.Run(t, c, func(t *rptesting.T) {
+//			t.Log("visible in go test and Report Portal")
+//			t.Run("negative", func(t *rptesting.T) {...})
+//		})
+//	}
+package rptesting
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skudasov/rpgoclient"
+)
+
+// Main starts launch, runs tests and finishes launch with PASSED or FAILED status by tests exit code,
+// reporting errors are printed to stderr and don't change the exit code
+func Main(m *testing.M, c *rpgoclient.Client, name string, description string, tags []string) int {
+	ctx := context.Background()
+	if _, err := c.StartLaunchContext(ctx, name, description, "", tags, "DEFAULT"); err != nil {
+		fmt.Fprintf(os.Stderr, "rptesting: start launch: %s\n", err)
+		return m.Run()
+	}
+	code := m.Run()
+	status := "PASSED"
+	if code != 0 {
+		status = "FAILED"
+	}
+	if _, err := c.FinishLaunchContext(ctx, status, ""); err != nil {
+		fmt.Fprintf(os.Stderr, "rptesting: finish launch: %s\n", err)
+	}
+	if err := c.Close(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "rptesting: %s\n", err)
+	}
+	return code
+}
+
+// T is testing.T which reports its logs to the test item
+type T struct {
+	*testing.T
+	item *rpgoclient.Item
+}
+
+// Run starts item named by t.Name() in current launch of the client and runs fn,
+// item is finished in t.Cleanup with FAILED, SKIPPED or PASSED status.
+// Without started launch fn runs without reporting
+func Run(t *testing.T, c *rpgoclient.Client, fn func(t *T)) {
+	t.Helper()
+	var item *rpgoclient.Item
+	if l := c.CurrentLaunch(); l != nil {
+		var err error
+		item, err = l.StartItem(context.Background(), t.Name(), "TEST", "", "", nil, nil)
+		if err != nil {
+			t.Logf("rptesting: start item: %s", err)
+		}
+	}
+	run(&T{T: t, item: item}, fn)
+}
+
+// Run runs fn as subtest name, its item is nested into item of t
+func (t *T) Run(name string, fn func(t *T)) bool {
+	t.Helper()
+	return t.T.Run(name, func(st *testing.T) {
+		var item *rpgoclient.Item
+		if t.item != nil {
+			var err error
+			item, err = t.item.StartChild(context.Background(), st.Name(), "STEP", "", "", nil, nil)
+			if err != nil {
+				st.Logf("rptesting: start item: %s", err)
+			}
+		}
+		run(&T{T: st, item: item}, fn)
+	})
+}
+
+// run calls fn and registers item finish, test which panicked is FAILED
+func run(t *T, fn func(t *T)) {
+	returned := false
+	t.Cleanup(func() {
+		if t.item == nil {
+			return
+		}
+		status := "PASSED"
+		switch {
+		case t.Failed() || (!returned && !t.Skipped()):
+			status = "FAILED"
+		case t.Skipped():
+			status = "SKIPPED"
+		}
+		if _, err := t.item.Finish(context.Background(), status, time.Now().Format(time.RFC3339), nil); err != nil {
+			t.T.Logf("rptesting: finish item: %s", err)
+		}
+	})
+	fn(t)
+	returned = true
+}
+
+// Item returns item of the test, nil if it's not reported
+func (t *T) Item() *rpgoclient.Item {
+	return t.item
+}
+
+func (t *T) report(level string, msg string) {
+	if t.item == nil {
+		return
+	}
+	if _, err := t.item.Log(context.Background(), strings.TrimSuffix(msg, "\n"), level); err != nil {
+		t.T.Logf("rptesting: log: %s", err)
+	}
+}
+
+func (t *T) Log(args ...interface{}) {
+	t.Helper()
+	t.report("INFO", fmt.Sprintln(args...))
+	t.T.Log(args...)
+}
+
+func (t *T) Logf(format string, args ...interface{}) {
+	t.Helper()
+	t.report("INFO", fmt.Sprintf(format, args...))
+	t.T.Logf(format, args...)
+}
+
+func (t *T) Error(args ...interface{}) {
+	t.Helper()
+	t.report("ERROR", fmt.Sprintln(args...))
+	t.T.Error(args...)
+}
+
+func (t *T) Errorf(format string, args ...interface{}) {
+	t.Helper()
+	t.report("ERROR", fmt.Sprintf(format, args...))
+	t.T.Errorf(format, args...)
+}
+
+func (t *T) Fatal(args ...interface{}) {
+	t.Helper()
+	t.report("ERROR", fmt.Sprintln(args...))
+	t.T.Fatal(args...)
+}
+
+func (t *T) Fatalf(format string, args ...interface{}) {
+	t.Helper()
+	t.report("ERROR", fmt.Sprintf(format, args...))
+	t.T.Fatalf(format, args...)
+}
+
+func (t *T) Skip(args ...interface{}) {
+	t.Helper()
+	t.report("INFO", fmt.Sprintln(args...))
+	t.T.Skip(args...)
+}
+
+func (t *T) Skipf(format string, args ...interface{}) {
+	t.Helper()
+	t.report("INFO", fmt.Sprintf(format, args...))
+	t.T.Skipf(format, args...)
+}
@@ -0,0 +1,72 @@
+package rptesting
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/skudasov/rpgoclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	var mu sync.Mutex
+	names := make(map[string]string)
+	statuses := make(map[string]string)
+	logs := make(map[string][]string)
+	var n int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/log"):
+			var p rpgoclient.LogPayload
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			logs[names[p.ItemId]] = append(logs[names[p.ItemId]], p.Level+" "+p.Message)
+		case r.Method == "POST":
+			var p rpgoclient.StartTestItemPayload
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			n++
+			names[fmt.Sprintf("id%d", n)] = p.Name
+			_, _ = fmt.Fprintf(w, `{"id": "id%d"}`, n)
+			return
+		case r.Method == "PUT":
+			var p rpgoclient.FinishTestItemPayload
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			statuses[names[path.Base(r.URL.Path)]] = p.Status
+		}
+		_, _ = w.Write([]byte(`{"msg": "ok"}`))
+	}))
+	defer ts.Close()
+	c := rpgoclient.New(ts.URL, "proj", "token", "", false, rpgoclient.WithVerbosity("error"))
+	_, err := c.StartLaunch("unit", "", "", nil, "DEFAULT")
+	assert.NoError(t, err)
+
+	t.Run("reported", func(t *testing.T) {
+		Run(t, c, func(t *T) {
+			t.Logf("value is %d", 1)
+			t.Run("passed", func(t *T) {
+				t.Log("checked")
+			})
+			t.Run("skipped", func(t *T) {
+				t.Skip("not ready")
+			})
+		})
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, map[string]string{
+		"TestRun/reported":         "PASSED",
+		"TestRun/reported/passed":  "PASSED",
+		"TestRun/reported/skipped": "SKIPPED",
+	}, statuses)
+	assert.Equal(t, []string{"INFO value is 1"}, logs["TestRun/reported"])
+	assert.Equal(t, []string{"INFO checked"}, logs["TestRun/reported/passed"])
+	assert.Equal(t, []string{"INFO not ready"}, logs["TestRun/reported/skipped"])
+}
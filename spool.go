@@ -0,0 +1,371 @@
+package rpgoclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+const spoolFileExt = ".jsonl"
+
+// spoolRecord is a reporting request which could not be delivered to the server
+type spoolRecord struct {
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	ContentType string `json:"contentType"`
+	Body        []byte `json:"body"`
+	// Id is launch or item id created by the request, it is remapped to a new id on replay
+	Id string `json:"id,omitempty"`
+	// ClientId is set when Id is generated on client side (v2 api) and sent in request body
+	ClientId bool `json:"clientId,omitempty"`
+	// Files are attachments of multipart request, Body is its json_request_part then
+	Files []spoolFile `json:"files,omitempty"`
+}
+
+// spoolFile is attachment of spooled multipart request, it is streamed to a separate file in spool dir
+type spoolFile struct {
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	// Path is file name relative to spool dir
+	Path string `json:"path"`
+}
+
+// spool appends undeliverable reporting requests to a JSONL file,
+// after the first spooled request client goes offline and spools every following reporting request,
+// so ids and ordering stay consistent for Replay
+type spool struct {
+	dir  string
+	path string
+
+	mu      sync.Mutex
+	offline bool
+}
+
+func newSpool(dir string) (*spool, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	name := fmt.Sprintf("%d-%s%s", time.Now().UnixNano(), newUUID(), spoolFileExt)
+	return &spool{dir: dir, path: filepath.Join(dir, name)}, nil
+}
+
+func (s *spool) isOffline() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.offline
+}
+
+// write spools request, request with body which can't be rebuilt with GetBody is refused with ErrBodyNotRewindable
+func (s *spool) write(req *http.Request, id string, clientId bool) error {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return fmt.Errorf("spool %s %s: %w", req.Method, req.URL.Path, ErrBodyNotRewindable)
+	}
+	rec := spoolRecord{
+		Method:      req.Method,
+		Path:        req.URL.Path,
+		ContentType: req.Header.Get("Content-Type"),
+		Id:          id,
+		ClientId:    clientId,
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return err
+		}
+		err = s.readBody(&rec, body)
+		body.Close()
+		if err != nil {
+			s.removeFiles(rec)
+			return err
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := appendSpoolRecords(s.path, []spoolRecord{rec}); err != nil {
+		s.removeFiles(rec)
+		return err
+	}
+	s.offline = true
+	return nil
+}
+
+// readBody reads body into record, attachments of multipart body are streamed to files, so they are never buffered in memory
+func (s *spool) readBody(rec *spoolRecord, body io.Reader) error {
+	mediaType, params, err := mime.ParseMediaType(rec.ContentType)
+	if err != nil || mediaType != "multipart/form-data" {
+		rec.Body, err = ioutil.ReadAll(body)
+		return err
+	}
+	mr := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if part.FormName() == "json_request_part" {
+			if rec.Body, err = ioutil.ReadAll(part); err != nil {
+				return err
+			}
+			continue
+		}
+		path, err := s.writeFile(part)
+		if err != nil {
+			return err
+		}
+		rec.Files = append(rec.Files, spoolFile{Name: part.FileName(), ContentType: part.Header.Get("Content-Type"), Path: path})
+	}
+}
+
+// writeFile streams r to a new file next to spool file and returns its name
+func (s *spool) writeFile(r io.Reader) (string, error) {
+	f, err := ioutil.TempFile(s.dir, strings.TrimSuffix(filepath.Base(s.path), spoolFileExt)+"-*.part")
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	return filepath.Base(f.Name()), f.Close()
+}
+
+func (s *spool) removeFiles(rec spoolRecord) {
+	removeSpoolFiles(s.dir, rec)
+}
+
+func removeSpoolFiles(dir string, rec spoolRecord) {
+	for _, f := range rec.Files {
+		os.Remove(filepath.Join(dir, f.Path))
+	}
+}
+
+func appendSpoolRecords(path string, recs []spoolRecord) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for _, rec := range recs {
+		if err := enc.Encode(rec); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+func readSpoolRecords(path string) ([]spoolRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var recs []spoolRecord
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1<<30)
+	for sc.Scan() {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		var rec spoolRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return nil, err
+		}
+		recs = append(recs, rec)
+	}
+	return recs, sc.Err()
+}
+
+// WithSpool enables spooling of reporting requests which failed after all retries into dir,
+// spooled runs can be delivered later with Replay
+func WithSpool(dir string) func(client *Client) error {
+	return func(c *Client) error {
+		s, err := newSpool(dir)
+		if err != nil {
+			return err
+		}
+		c.spool = s
+		return nil
+	}
+}
+
+// report sends reporting request, id is launch or item id created by the request, if any.
+// When spool is enabled undeliverable requests are spooled and spooled is true
+func (c *Client) report(req *http.Request, v interface{}, id string) (spooled bool, err error) {
+	clientId := id != "" && c.APIVersion == 2
+	if c.spool != nil && c.spool.isOffline() {
+		if err := c.spool.write(req, id, clientId); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	_, err = c.do(req, v)
+	if c.spool != nil && undeliverable(req, err) {
+		c.l.Warnf("server is unreachable, spooling reporting to %s", c.spool.path)
+		if werr := c.spool.write(req, id, clientId); werr != nil {
+			return false, multierr.Append(err, werr)
+		}
+		return true, nil
+	}
+	return false, err
+}
+
+// undeliverable reports whether request failed because server is unreachable or failed to process it.
+// Requests abandoned by caller are not spooled, they may have reached the server and would be duplicated by Replay
+func undeliverable(req *http.Request, err error) bool {
+	if err == nil || req.Context().Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, ErrHTTPRetriesReached) {
+		return true
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// Replay delivers runs spooled in dir, ids of spooled launches and items are remapped to the ids
+// created on replay, delivered files are removed, on failure file is rewritten with the rest of requests
+func (c *Client) Replay(ctx context.Context, dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+spoolFileExt))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		if err := c.replayFile(ctx, f); err != nil {
+			return fmt.Errorf("replay %s: %w", f, err)
+		}
+	}
+	return nil
+}
+
+func (c *Client) replayFile(ctx context.Context, path string) error {
+	recs, err := readSpoolRecords(path)
+	if err != nil {
+		return err
+	}
+	ids := make(map[string]string)
+	for i, rec := range recs {
+		if rec.Id != "" && rec.ClientId {
+			ids[rec.Id] = newUUID()
+		}
+		rec = remapSpoolRecord(rec, ids)
+		req, done, err := c.spooledRequest(ctx, filepath.Dir(path), rec)
+		if err != nil {
+			return err
+		}
+		var respBody StartTestItemResponse
+		_, err = c.do(req, &respBody)
+		done()
+		if err != nil {
+			if rec.Id != "" && rec.ClientId {
+				delete(ids, rec.Id)
+			}
+			rest := make([]spoolRecord, 0, len(recs)-i)
+			for _, r := range recs[i:] {
+				rest = append(rest, remapSpoolRecord(r, ids))
+			}
+			if werr := rewriteSpoolFile(path, rest); werr != nil {
+				return werr
+			}
+			return err
+		}
+		if rec.Id != "" && !rec.ClientId {
+			ids[rec.Id] = respBody.Id
+		}
+		removeSpoolFiles(filepath.Dir(path), rec)
+	}
+	return os.Remove(path)
+}
+
+// spooledRequest returns request of spooled record, multipart request is rebuilt with attachments streamed
+// from spooled files, done must be called when request is sent
+func (c *Client) spooledRequest(ctx context.Context, dir string, rec spoolRecord) (req *http.Request, done func(), err error) {
+	if !strings.HasPrefix(rec.ContentType, "multipart/") {
+		req, err = c.newRequest(ctx, rec.Method, rec.Path, bytes.NewBuffer(rec.Body), rec.ContentType)
+		return req, func() {}, err
+	}
+	var payload interface{}
+	if len(rec.Body) > 0 {
+		payload = json.RawMessage(rec.Body)
+	}
+	var files []*os.File
+	closeFiles := func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}
+	attachments := make([]Attachment, 0, len(rec.Files))
+	for _, sf := range rec.Files {
+		f, err := os.Open(filepath.Join(dir, sf.Path))
+		if err != nil {
+			closeFiles()
+			return nil, nil, err
+		}
+		files = append(files, f)
+		attachments = append(attachments, Attachment{Name: sf.Name, ContentType: sf.ContentType, Reader: f})
+	}
+	body, err := newMultipartBody(payload, attachments)
+	if err != nil {
+		closeFiles()
+		return nil, nil, err
+	}
+	r, err := body.open()
+	if err != nil {
+		closeFiles()
+		return nil, nil, err
+	}
+	done = func() {
+		body.close()
+		closeFiles()
+	}
+	req, err = c.newRequest(ctx, rec.Method, rec.Path, r, body.contentType())
+	if err != nil {
+		done()
+		return nil, nil, err
+	}
+	req.GetBody = body.open
+	return req, done, nil
+}
+
+func rewriteSpoolFile(path string, recs []spoolRecord) error {
+	tmp := path + ".tmp"
+	if err := appendSpoolRecords(tmp, recs); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// remapSpoolRecord replaces spooled ids with ids created on replay in request path and body
+func remapSpoolRecord(rec spoolRecord, ids map[string]string) spoolRecord {
+	for from, to := range ids {
+		rec.Path = strings.Replace(rec.Path, from, to, -1)
+		rec.Body = bytes.Replace(rec.Body, []byte(from), []byte(to), -1)
+	}
+	return rec
+}
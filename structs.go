@@ -59,14 +59,28 @@ type Issue struct {
 }
 
 type FinishTestItemResponse struct {
-	Msg string `json:"msg"`
+	Msg     string `json:"msg"`
+	Message string `json:"message"`
+}
+
+// message returns finish message of either v1 or v2 api
+func (r FinishTestItemResponse) message() string {
+	if r.Msg != "" {
+		return r.Msg
+	}
+	return r.Message
 }
 
 type LogPayload struct {
-	ItemId  string `json:"item_id"`
-	Time    string `json:"time"`
-	Message string `json:"message"`
-	Level   string `json:"level"`
+	ItemId  string   `json:"item_id"`
+	Time    string   `json:"time"`
+	Message string   `json:"message"`
+	Level   string   `json:"level"`
+	File    *LogFile `json:"file,omitempty"`
+}
+
+type LogFile struct {
+	Name string `json:"name"`
 }
 
 type LogResponse struct {
@@ -84,3 +98,59 @@ type GetItemIdByUniqIdResponse struct {
 type ItemContent struct {
 	Id int `json:"id"`
 }
+
+type Attribute struct {
+	Key    string `json:"key,omitempty"`
+	Value  string `json:"value"`
+	System bool   `json:"system,omitempty"`
+}
+
+type StartLaunchPayloadV2 struct {
+	Uuid        string      `json:"uuid,omitempty"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Attributes  []Attribute `json:"attributes"`
+	StartTime   int64       `json:"startTime"`
+	Mode        string      `json:"mode"`
+}
+
+type FinishLaunchPayloadV2 struct {
+	Status  string `json:"status"`
+	EndTime int64  `json:"endTime"`
+}
+
+type Parameter struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type StartTestItemPayloadV2 struct {
+	Uuid        string      `json:"uuid,omitempty"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Attributes  []Attribute `json:"attributes"`
+	StartTime   int64       `json:"startTime"`
+	LaunchUuid  string      `json:"launchUuid"`
+	Type        string      `json:"type"`
+	Parameters  []Parameter `json:"parameters"`
+}
+
+type FinishTestItemPayloadV2 struct {
+	Status     string                 `json:"status"`
+	EndTime    int64                  `json:"endTime"`
+	LaunchUuid string                 `json:"launchUuid"`
+	Issue      map[string]interface{} `json:"issue,omitempty"`
+}
+
+type LogPayloadV2 struct {
+	ItemUuid   string   `json:"itemUuid"`
+	LaunchUuid string   `json:"launchUuid"`
+	Time       int64    `json:"time"`
+	Message    string   `json:"message"`
+	Level      string   `json:"level"`
+	File       *LogFile `json:"file,omitempty"`
+}
+
+type LogBatchResponse struct {
+	Responses []LogResponse `json:"responses"`
+}
@@ -0,0 +1,162 @@
+package rpgoclient
+
+import (
+	"context"
+	"regexp"
+	"sync"
+
+	"go.uber.org/multierr"
+)
+
+// ticketScanner collects tickets referenced in log messages of items and links them to items finished as FAILED
+type ticketScanner struct {
+	c        *Client
+	patterns []*regexp.Regexp
+
+	mu      sync.Mutex
+	tickets map[string][]string
+	// pending are links of asynchronously finished items, they are made on Flush when items are delivered
+	pending []pendingLink
+	errs    error
+}
+
+type pendingLink struct {
+	itemId  string
+	tickets []string
+}
+
+// WithTicketScanner scans messages of Log, LogId and LogBatch for tickets matched by patterns,
+// ticket id is the first capture group or the whole match, e.g. `KNOWN-BUG: ([A-Z]+-\d+)`.
+// Tickets found in logs of item are linked to it when it is finished as FAILED, tickets are parsed with
+// WithBTS bug tracking systems, linking errors are returned by Flush and Close
+func WithTicketScanner(patterns ...string) func(client *Client) error {
+	return func(c *Client) error {
+		s := &ticketScanner{c: c, tickets: make(map[string][]string)}
+		for _, p := range patterns {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return err
+			}
+			s.patterns = append(s.patterns, re)
+		}
+		c.scanner = s
+		return nil
+	}
+}
+
+// scan remembers tickets referenced in message of item
+func (s *ticketScanner) scan(itemId string, message string) {
+	var found []string
+	for _, re := range s.patterns {
+		for _, m := range re.FindAllStringSubmatch(message, -1) {
+			ticket := m[0]
+			if len(m) > 1 && m[1] != "" {
+				ticket = m[1]
+			}
+			found = append(found, ticket)
+		}
+	}
+	if len(found) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range found {
+		if !contains(s.tickets[itemId], t) {
+			s.tickets[itemId] = append(s.tickets[itemId], t)
+		}
+	}
+}
+
+// forget drops tickets of item, it's called on every finish of item whether the finish is sent or not
+func (s *ticketScanner) forget(itemId string) {
+	s.mu.Lock()
+	delete(s.tickets, itemId)
+	s.mu.Unlock()
+}
+
+// finished links tickets of item finished as FAILED, links of asynchronously reported
+// items are deferred until Flush
+func (s *ticketScanner) finished(ctx context.Context, itemId string, status string) {
+	s.mu.Lock()
+	tickets := s.tickets[itemId]
+	if status != "FAILED" || len(tickets) == 0 {
+		s.mu.Unlock()
+		return
+	}
+	if s.c.async != nil {
+		s.pending = append(s.pending, pendingLink{itemId: itemId, tickets: tickets})
+		s.mu.Unlock()
+		return
+	}
+	s.mu.Unlock()
+	if err := s.link(ctx, itemId, tickets); err != nil {
+		s.addError(err)
+	}
+}
+
+func (s *ticketScanner) link(ctx context.Context, itemId string, tickets []string) error {
+	id, err := s.c.GetItemIdByUUIDContext(ctx, itemId)
+	if err != nil {
+		return err
+	}
+	var errs error
+	issues := make([]Issue, 0, len(tickets))
+	for _, t := range tickets {
+		issue, err := s.c.ticketIssue(t)
+		if err != nil {
+			errs = multierr.Append(errs, err)
+			continue
+		}
+		issues = append(issues, issue)
+	}
+	if len(issues) == 0 {
+		return errs
+	}
+	_, err = s.c.LinkIssues(ctx, []int{id.Id}, issues)
+	return multierr.Append(errs, err)
+}
+
+func (s *ticketScanner) addError(err error) {
+	s.c.l.Errorf("failed to link tickets found in logs: %s", err)
+	s.mu.Lock()
+	s.errs = multierr.Append(s.errs, err)
+	s.mu.Unlock()
+}
+
+// flush makes pending links and returns errors collected since last flush
+func (s *ticketScanner) flush(ctx context.Context) error {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+	for _, p := range pending {
+		if err := s.link(ctx, p.itemId, p.tickets); err != nil {
+			s.addError(err)
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	errs := s.errs
+	s.errs = nil
+	return errs
+}
+
+// scanLogs scans messages of LogBatch for tickets
+func (c *Client) scanLogs(messages []LogPayload) {
+	if c.scanner == nil {
+		return
+	}
+	for _, m := range messages {
+		c.scanner.scan(m.ItemId, m.Message)
+	}
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
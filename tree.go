@@ -0,0 +1,109 @@
+package rpgoclient
+
+import (
+	"context"
+	"sync"
+)
+
+// Launch is a launch handle safe for concurrent use, items started from it
+// don't use Client stack, so parallel tests can report independently
+type Launch struct {
+	Id string
+
+	c     *Client
+	mu    sync.Mutex
+	items map[string]*Item
+}
+
+// Item is a test item handle safe for concurrent use
+type Item struct {
+	Id string
+
+	launch *Launch
+	parent *Item
+}
+
+// StartLaunchHandle starts launch and returns its handle, Client stack is not changed
+func (c *Client) StartLaunchHandle(ctx context.Context, name string, description string, startTimeStringRFC3339 string, tags []string, mode string) (*Launch, error) {
+	resp, err := c.startLaunch(ctx, name, description, startTimeStringRFC3339, tags, mode)
+	if err != nil {
+		return nil, err
+	}
+	return c.LaunchHandle(resp.Id), nil
+}
+
+// LaunchHandle returns handle of already started launch
+func (c *Client) LaunchHandle(id string) *Launch {
+	return &Launch{Id: id, c: c, items: make(map[string]*Item)}
+}
+
+// StartItem starts root item of the launch
+func (l *Launch) StartItem(ctx context.Context, name string, itemType string, startTimeStringRFC3339 string, description string, tags []string, parameters []map[string]string) (*Item, error) {
+	it, _, err := l.startItem(ctx, nil, name, itemType, startTimeStringRFC3339, description, tags, parameters)
+	return it, err
+}
+
+// Finish finishes the launch
+func (l *Launch) Finish(ctx context.Context, status string, endTimeStringRFC3339 string) (FinishLaunchResponse, error) {
+	return l.c.finishLaunch(ctx, l.Id, status, endTimeStringRFC3339)
+}
+
+// OpenItems returns items of the launch which are started but not finished yet
+func (l *Launch) OpenItems() []*Item {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	items := make([]*Item, 0, len(l.items))
+	for _, it := range l.items {
+		items = append(items, it)
+	}
+	return items
+}
+
+func (l *Launch) startItem(ctx context.Context, parent *Item, name string, itemType string, startTimeStringRFC3339 string, description string, tags []string, parameters []map[string]string) (*Item, StartTestItemResponse, error) {
+	var parentId string
+	if parent != nil {
+		parentId = parent.Id
+	}
+	resp, err := l.c.startItem(ctx, l.Id, parentId, name, itemType, startTimeStringRFC3339, description, tags, parameters)
+	if err != nil {
+		return nil, StartTestItemResponse{}, err
+	}
+	it := &Item{Id: resp.Id, launch: l, parent: parent}
+	l.mu.Lock()
+	l.items[it.Id] = it
+	l.mu.Unlock()
+	return it, resp, nil
+}
+
+// Launch returns launch of the item
+func (i *Item) Launch() *Launch {
+	return i.launch
+}
+
+// Parent returns parent item, nil for root items
+func (i *Item) Parent() *Item {
+	return i.parent
+}
+
+// StartChild starts nested item
+func (i *Item) StartChild(ctx context.Context, name string, itemType string, startTimeStringRFC3339 string, description string, tags []string, parameters []map[string]string) (*Item, error) {
+	it, _, err := i.launch.startItem(ctx, i, name, itemType, startTimeStringRFC3339, description, tags, parameters)
+	return it, err
+}
+
+// Log attaches log to the item
+func (i *Item) Log(ctx context.Context, message string, level string) (string, error) {
+	return i.launch.c.logItem(ctx, i.launch.Id, i.Id, message, level)
+}
+
+// Finish finishes the item
+func (i *Item) Finish(ctx context.Context, status string, endTimeStringRFC3339 string, issue map[string]interface{}) (string, error) {
+	msg, err := i.launch.c.finishItem(ctx, i.launch.Id, i.Id, status, endTimeStringRFC3339, issue)
+	if !finishSent(err) {
+		return "", err
+	}
+	i.launch.mu.Lock()
+	delete(i.launch.items, i.Id)
+	i.launch.mu.Unlock()
+	return msg, err
+}
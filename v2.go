@@ -0,0 +1,205 @@
+package rpgoclient
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// apiV1URL returns url of non reporting api, v2 covers only launches, items and logs reporting
+func (c *Client) apiV1URL() string {
+	if c.APIVersion == 2 {
+		return "/api/v1"
+	}
+	return c.ApiURL
+}
+
+// newClientId generates launch or item uuid on client side, so v2 reporting does not depend on server response ids,
+// returns empty string for v1 api where ids are assigned by server
+func (c *Client) newClientId() string {
+	if c.APIVersion == 2 {
+		return newUUID()
+	}
+	return ""
+}
+
+// clientIdStart reports whether request starts launch or item with uuid generated on client side
+func (c *Client) clientIdStart(req *http.Request) bool {
+	if c.APIVersion != 2 || req.Method != http.MethodPost {
+		return false
+	}
+	prefix := fmt.Sprintf("%s/%s/", c.ApiURL, c.Project)
+	i := strings.Index(req.URL.Path, prefix)
+	if i < 0 {
+		return false
+	}
+	switch rest := req.URL.Path[i+len(prefix):]; {
+	case rest == "launch", rest == "item":
+		return true
+	case strings.HasPrefix(rest, "item/"):
+		return !strings.Contains(strings.TrimPrefix(rest, "item/"), "/")
+	}
+	return false
+}
+
+// placeholderId returns client side id or a new placeholder id used for v1 requests which are spooled
+func placeholderId(clientId string) string {
+	if clientId != "" {
+		return clientId
+	}
+	return newUUID()
+}
+
+// rfc3339ToMillis converts RFC3339 time used by v1 api to epoch millis expected by v2 api
+func rfc3339ToMillis(s string) (int64, error) {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return 0, err
+	}
+	return t.UnixNano() / int64(time.Millisecond), nil
+}
+
+// tagsToAttributes converts v1 tags to v2 attributes, "key:value" tags are split into key and value
+func tagsToAttributes(tags []string) []Attribute {
+	attrs := make([]Attribute, 0, len(tags))
+	for _, t := range tags {
+		kv := strings.SplitN(t, ":", 2)
+		if len(kv) == 2 {
+			attrs = append(attrs, Attribute{Key: kv[0], Value: kv[1]})
+			continue
+		}
+		attrs = append(attrs, Attribute{Value: t})
+	}
+	return attrs
+}
+
+func mapsToParameters(params []map[string]string) []Parameter {
+	res := make([]Parameter, 0, len(params))
+	for _, p := range params {
+		res = append(res, Parameter{Key: p["key"], Value: p["value"]})
+	}
+	return res
+}
+
+func (c *Client) startLaunchPayload(uuid string, name string, description string, startTime string, tags []string, mode string) (interface{}, error) {
+	if c.APIVersion == 2 {
+		ms, err := rfc3339ToMillis(startTime)
+		if err != nil {
+			return nil, err
+		}
+		return StartLaunchPayloadV2{
+			Uuid:        uuid,
+			Name:        name,
+			StartTime:   ms,
+			Description: description,
+			Attributes:  tagsToAttributes(tags),
+			Mode:        mode,
+		}, nil
+	}
+	return StartLaunchPayload{
+		Name:        name,
+		StartTime:   startTime,
+		Description: description,
+		Tags:        tags,
+		Mode:        mode,
+	}, nil
+}
+
+func (c *Client) finishLaunchPayload(status string, endTime string) (interface{}, error) {
+	if c.APIVersion == 2 {
+		ms, err := rfc3339ToMillis(endTime)
+		if err != nil {
+			return nil, err
+		}
+		return FinishLaunchPayloadV2{
+			Status:  status,
+			EndTime: ms,
+		}, nil
+	}
+	return FinishLaunchPayload{
+		Status:  status,
+		EndTime: endTime,
+	}, nil
+}
+
+func (c *Client) startTestItemPayload(uuid string, launchId string, name string, itemType string, startTime string, description string, tags []string, parameters []map[string]string) (interface{}, error) {
+	if c.APIVersion == 2 {
+		ms, err := rfc3339ToMillis(startTime)
+		if err != nil {
+			return nil, err
+		}
+		return StartTestItemPayloadV2{
+			Uuid:        uuid,
+			Name:        name,
+			StartTime:   ms,
+			Description: description,
+			Attributes:  tagsToAttributes(tags),
+			LaunchUuid:  launchId,
+			Type:        itemType,
+			Parameters:  mapsToParameters(parameters),
+		}, nil
+	}
+	return StartTestItemPayload{
+		Name:        name,
+		StartTime:   startTime,
+		Description: description,
+		Tags:        tags,
+		LaunchId:    launchId,
+		Type:        itemType,
+		Parameters:  parameters,
+	}, nil
+}
+
+func (c *Client) finishTestItemPayload(launchId string, status string, endTime string, issue map[string]interface{}) (interface{}, error) {
+	if c.APIVersion == 2 {
+		ms, err := rfc3339ToMillis(endTime)
+		if err != nil {
+			return nil, err
+		}
+		return FinishTestItemPayloadV2{
+			EndTime:    ms,
+			Status:     status,
+			LaunchUuid: launchId,
+			Issue:      issue,
+		}, nil
+	}
+	return FinishTestItemPayload{
+		EndTime: endTime,
+		Status:  status,
+		Issue:   issue,
+	}, nil
+}
+
+func (c *Client) logPayload(launchId string, p LogPayload) (interface{}, error) {
+	if c.APIVersion == 2 {
+		ms, err := rfc3339ToMillis(p.Time)
+		if err != nil {
+			return nil, err
+		}
+		return LogPayloadV2{
+			ItemUuid:   p.ItemId,
+			LaunchUuid: launchId,
+			Time:       ms,
+			Message:    p.Message,
+			Level:      p.Level,
+			File:       p.File,
+		}, nil
+	}
+	return p, nil
+}
+
+func (c *Client) logBatchPayload(launchId string, messages []LogPayload) (interface{}, error) {
+	if c.APIVersion == 2 {
+		res := make([]interface{}, 0, len(messages))
+		for _, m := range messages {
+			p, err := c.logPayload(launchId, m)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, p)
+		}
+		return res, nil
+	}
+	return messages, nil
+}